@@ -0,0 +1,264 @@
+// Package aontrs implements AONT-RS dispersal (Resch & Plank, "AONT-RS:
+// Blending Security and Performance in Dispersed Storage Systems").
+//
+// The secret is first transformed with an all-or-nothing transform: it is
+// encrypted with a random key, and the key is hidden in the last block of
+// the transformed data by XOR-ing it with the hash of the ciphertext. The
+// transformed data is then encoded with reed-solomon. No one can recover
+// the key, hence the secret, without `threshold` shares, and unlike SSMS
+// no shamir-shared metadata is needed.
+package aontrs
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/fadhilkurnia/shamir/csprng"
+	"github.com/klauspost/reedsolomon"
+	"math"
+	"sync"
+)
+
+// key size: 32 bytes (AES-256), the same as the size of SHA-256 digest
+// canary size: 16 bytes, appended to the secret to check the integrity
+// data len type: uint32 (4 bytes), support up to 4 GB secret data
+
+const LenKey = sha256.Size
+const LenCanary = 16
+const LenLen = 4
+
+// ShareOverhead is the byte size of the header in each share: the
+// secret length, one byte #parts, one byte #threshold, and one byte part-id.
+const ShareOverhead = LenLen + 3
+
+type encoderKey struct {
+	dataShards   int
+	parityShards int
+}
+
+// encoderCache keeps a single reed-solomon encoder for each (data, parity)
+// configuration, the encoders are safe for concurrent use.
+var encoderCache sync.Map
+
+func getEncoder(parts, threshold int) (reedsolomon.Encoder, error) {
+	key := encoderKey{threshold, parts - threshold}
+	if enc, ok := encoderCache.Load(key); ok {
+		return enc.(reedsolomon.Encoder), nil
+	}
+	enc, err := reedsolomon.New(threshold, parts-threshold)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := encoderCache.LoadOrStore(key, enc)
+	return actual.(reedsolomon.Encoder), nil
+}
+
+func checkParams(parts, threshold int) error {
+	if threshold == 0 || parts == 0 {
+		return errors.New("#parts and #threshold can not be zero")
+	}
+	if threshold > parts {
+		return fmt.Errorf(
+			"threshold should be less to the number of parts, #parts=%d $threshold=%d", parts, threshold)
+	}
+	if threshold > 255 || parts > 255 {
+		return fmt.Errorf(
+			"#parts and #threshold should be less than 256, #parts=%d $threshold=%d", parts, threshold)
+	}
+	return nil
+}
+
+// newStream returns the AES-CTR key stream used in the transform. The IV is
+// always zero since every secret is encrypted with a fresh key.
+func newStream(key []byte) (cipher.Stream, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var iv [aes.BlockSize]byte
+	return cipher.NewCTR(block, iv[:]), nil
+}
+
+// Split disperses the secret into `parts` shares, `threshold` of which are
+// required to reconstruct the secret. Each share is ceil((len(secret)+48)/threshold)
+// bytes long, plus ShareOverhead bytes of header. The key is generated with
+// crypto/rand.
+func Split(secret []byte, parts, threshold int) ([][]byte, error) {
+	return split(secret, parts, threshold, nil)
+}
+
+// SplitWithRandomizer is exactly the same with Split but with randomizer provided by the caller
+func SplitWithRandomizer(secret []byte, parts, threshold int, randomizer *csprng.CSPRNG) ([][]byte, error) {
+	return split(secret, parts, threshold, randomizer)
+}
+
+func split(secret []byte, parts, threshold int, randomizer *csprng.CSPRNG) ([][]byte, error) {
+	if len(secret) > math.MaxUint32-LenCanary-LenKey {
+		return nil, fmt.Errorf(
+			"the provided secret is to large, we can only split up to %d bytes data", math.MaxUint32-LenCanary-LenKey)
+	}
+	if len(secret) == 0 {
+		return nil, errors.New("cannot split an empty secret")
+	}
+	if err := checkParams(parts, threshold); err != nil {
+		return nil, err
+	}
+	encoder, err := getEncoder(parts, threshold)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize reed-solomon encoder: %v", err)
+	}
+
+	// generate random key, the secret is only as safe as the key is
+	// unpredictable since it is not secret-shared
+	key := make([]byte, LenKey)
+	if randomizer != nil {
+		_, err = randomizer.Read(key)
+	} else {
+		_, err = rand.Read(key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate secret key: %v", err)
+	}
+
+	// the package is {E(secret || canary), key ^ H(E(secret || canary))},
+	// written directly into the data shards
+	lenCiphertext := len(secret) + LenCanary
+	lenPackage := lenCiphertext + LenKey
+	shardSize := (lenPackage + threshold - 1) / threshold
+	shardsBuff := make([]byte, shardSize*parts)
+	shards := make([][]byte, parts)
+	for i := 0; i < parts; i++ {
+		shards[i] = shardsBuff[i*shardSize : (i+1)*shardSize]
+	}
+
+	stream, err := newStream(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize aes: %v", err)
+	}
+	ciphertext := shardsBuff[:lenCiphertext]
+	copy(ciphertext, secret) // the canary is zeros
+	stream.XORKeyStream(ciphertext, ciphertext)
+	digest := sha256.Sum256(ciphertext)
+	maskedKey := shardsBuff[lenCiphertext:lenPackage]
+	for i := 0; i < LenKey; i++ {
+		maskedKey[i] = key[i] ^ digest[i]
+	}
+
+	// generate the parity shards
+	if err := encoder.Encode(shards); err != nil {
+		return nil, fmt.Errorf("failed to encode the secret: %v", err)
+	}
+
+	// each share is {reed-solomon shard, secret length, #parts, #threshold, part-id}
+	results := newByteMatrix(parts, shardSize+ShareOverhead)
+	for i := 0; i < parts; i++ {
+		copy(results[i], shards[i])
+		binary.LittleEndian.PutUint32(results[i][shardSize:], uint32(len(secret)))
+		results[i][shardSize+LenLen] = byte(parts)
+		results[i][shardSize+LenLen+1] = byte(threshold)
+		results[i][shardSize+LenLen+2] = byte(i)
+	}
+
+	return results, nil
+}
+
+func newByteMatrix(r, c int) [][]byte {
+	a := make([]uint8, r*c)
+	m := make([][]uint8, r)
+	lo, hi := 0, c
+	for i := range m {
+		m[i] = a[lo:hi:hi]
+		lo, hi = hi, hi+c
+	}
+	return m
+}
+
+// Combine reconstructs the secret from at least `threshold` shares. The shares
+// for missing parts can be omitted or given as nil. An error is returned if the
+// integrity check of the reconstructed secret fails.
+func Combine(shares [][]byte, parts, threshold int) ([]byte, error) {
+	if err := checkParams(parts, threshold); err != nil {
+		return nil, err
+	}
+	encoder, err := getEncoder(parts, threshold)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize reed-solomon decoder: %v", err)
+	}
+
+	// remove empty shares
+	cleanShares := make([][]byte, 0, len(shares))
+	for i := 0; i < len(shares); i++ {
+		if shares[i] != nil {
+			cleanShares = append(cleanShares, shares[i])
+		}
+	}
+	shares = cleanShares
+	if len(shares) == 0 {
+		return nil, errors.New("no secret-shared data is given")
+	}
+
+	shardSize := len(shares[0]) - ShareOverhead
+	if shardSize <= 0 {
+		return nil, errors.New("the given secret-shared data is too short")
+	}
+	length := int(binary.LittleEndian.Uint32(shares[0][shardSize:]))
+	lenCiphertext := length + LenCanary
+	lenPackage := lenCiphertext + LenKey
+	if lenPackage > shardSize*threshold {
+		return nil, errors.New("the given secret-shared data is wrong, secret length exceeds the data shards")
+	}
+
+	shards := make([][]byte, parts)
+	for i := 0; i < len(shares); i++ {
+		if len(shares[i]) != len(shares[0]) {
+			return nil, errors.New("all the secret-shared data must be the same length")
+		}
+		if int(binary.LittleEndian.Uint32(shares[i][shardSize:])) != length {
+			return nil, errors.New("all the secret-shared data must have the same secret length")
+		}
+		if int(shares[i][shardSize+LenLen]) != parts || int(shares[i][shardSize+LenLen+1]) != threshold {
+			return nil, fmt.Errorf("the given secret-shared data is not generated for #parts=%d #threshold=%d", parts, threshold)
+		}
+		partID := shares[i][shardSize+LenLen+2]
+		if int(partID) >= parts {
+			return nil, errors.New("the given secret-shared data is wrong, part-id should be less than the number of parts")
+		}
+		shards[partID] = shares[i][:shardSize]
+	}
+	if err = encoder.ReconstructData(shards); err != nil {
+		return nil, fmt.Errorf("failed to reconstruct data: %v", err)
+	}
+
+	// join the data shards to get the package
+	pkg := make([]byte, 0, shardSize*threshold)
+	for i := 0; i < threshold; i++ {
+		pkg = append(pkg, shards[i]...)
+	}
+	ciphertext := pkg[:lenCiphertext]
+
+	// recover the key from the package, then decrypt the secret
+	digest := sha256.Sum256(ciphertext)
+	key := make([]byte, LenKey)
+	for i := 0; i < LenKey; i++ {
+		key[i] = pkg[lenCiphertext+i] ^ digest[i]
+	}
+	stream, err := newStream(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt the decoded ciphertext: %v", err)
+	}
+	stream.XORKeyStream(ciphertext, ciphertext)
+	for _, c := range ciphertext[length:] {
+		if c != 0 {
+			return nil, errors.New("integrity check failed, the given secret-shared data is corrupted")
+		}
+	}
+
+	secret := make([]byte, length)
+	copy(secret, ciphertext)
+	return secret, nil
+}
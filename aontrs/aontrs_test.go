@@ -0,0 +1,75 @@
+package aontrs
+
+import (
+	"github.com/fadhilkurnia/shamir/csprng"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestSplitCombine(t *testing.T) {
+	secretMsg := []byte("01234567891234567890012345678909876543210987654321")
+	parts := 5
+	threshold := 2
+
+	shares, err := Split(secretMsg, parts, threshold)
+	if err != nil {
+		t.Fatalf("failed to split the message: %v", err)
+	}
+	combinedShares, err := Combine(shares[3:], parts, threshold)
+	if err != nil {
+		t.Fatalf("failed to combine the message: %v", err)
+	}
+
+	t.Logf("original len: %d, encoded len: %d", len(secretMsg), len(shares[0]))
+
+	isEqual := reflect.DeepEqual(secretMsg, combinedShares)
+	if !isEqual {
+		t.Errorf("The combined secret is different. Expected: '%v', but got '%v'.\n", string(secretMsg), string(combinedShares))
+	}
+	expectedLen := (len(secretMsg)+LenCanary+LenKey+threshold-1)/threshold + ShareOverhead
+	if len(shares[0]) != expectedLen {
+		t.Errorf("the expected length of a single share is %d, but got %d", expectedLen, len(shares[0]))
+	}
+}
+
+func TestSplitCombineVaryT(t *testing.T) {
+	r := csprng.NewCSPRNG()
+	maxParts := 20
+
+	for size := 1; size < 2_000; size += 99 {
+		secretMsg := make([]byte, size)
+		rand.Read(secretMsg)
+		for th := 1; th <= maxParts; th++ {
+			shares, err := SplitWithRandomizer(secretMsg, maxParts, th, r)
+			if err != nil {
+				t.Fatalf("failed to split the message: %v", err)
+			}
+			for i := 0; i < maxParts-th; i++ {
+				shares[(i*7)%maxParts] = nil
+			}
+			combinedShares, err := Combine(shares, maxParts, th)
+			if err != nil {
+				t.Fatalf("failed to combine the message: %v", err)
+			}
+
+			isEqual := reflect.DeepEqual(secretMsg, combinedShares)
+			if !isEqual {
+				t.Errorf("The combined secret is different. Expected: '%v', but got '%v'.\n", string(secretMsg), string(combinedShares))
+			}
+		}
+	}
+}
+
+func TestCombineCorrupted(t *testing.T) {
+	secretMsg := []byte("The quick brown fox jumps over the lazy dog.")
+
+	shares, err := Split(secretMsg, 4, 2)
+	if err != nil {
+		t.Fatalf("failed to split the message: %v", err)
+	}
+	shares[1][3] ^= 0x01
+	if _, err := Combine(shares[:2], 4, 2); err == nil {
+		t.Errorf("expecting an error when combining corrupted shares")
+	}
+}
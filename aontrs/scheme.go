@@ -0,0 +1,38 @@
+package aontrs
+
+import (
+	"errors"
+	"github.com/fadhilkurnia/shamir/csprng"
+)
+
+// Scheme is AONT-RS as a secret-sharing scheme, the number of parts and
+// the threshold are read from the shares when combining.
+type Scheme struct{}
+
+// Name returns the name of the scheme.
+func (Scheme) Name() string {
+	return "aontrs"
+}
+
+// Split is the same as SplitWithRandomizer.
+func (Scheme) Split(secret []byte, parts, threshold int, randomizer *csprng.CSPRNG) ([][]byte, error) {
+	return split(secret, parts, threshold, randomizer)
+}
+
+// Combine reconstructs the secret from the shares, see the package-level Combine.
+func (Scheme) Combine(shares [][]byte) ([]byte, error) {
+	for _, share := range shares {
+		if len(share) >= ShareOverhead {
+			parts := int(share[len(share)-3])
+			threshold := int(share[len(share)-2])
+			return Combine(shares, parts, threshold)
+		}
+	}
+	return nil, errors.New("no secret-shared data is given")
+}
+
+// ShareOverhead returns how many bytes each share is larger than
+// len(secret)/threshold, the smallest possible share size.
+func (Scheme) ShareOverhead(secretLen, parts, threshold int) int {
+	return (secretLen+LenCanary+LenKey+threshold-1)/threshold + ShareOverhead - (secretLen+threshold-1)/threshold
+}
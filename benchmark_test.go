@@ -8,9 +8,11 @@ import (
 	rand2 "crypto/rand"
 	"crypto/rsa"
 	"fmt"
+	"github.com/fadhilkurnia/shamir/aontrs"
 	"github.com/fadhilkurnia/shamir/csprng"
 	"github.com/fadhilkurnia/shamir/krawczyk"
 	"github.com/fadhilkurnia/shamir/shamir"
+	"github.com/fadhilkurnia/shamir/worker"
 	hcShamir "github.com/hashicorp/vault/shamir"
 	"github.com/klauspost/reedsolomon"
 	"math"
@@ -257,6 +259,26 @@ func BenchmarkSplitKrawczyk100(b *testing.B) {
 	}
 }
 
+func BenchmarkSplitterKrawczyk100(b *testing.B) {
+	s, _ := krawczyk.NewSplitter(4, 2)
+	r := csprng.NewCSPRNG()
+	b.SetBytes(int64(len(bytes100)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = s.SplitWithRandomizer(bytes100, r)
+	}
+}
+
+func BenchmarkSplitterKrawczyk1K(b *testing.B) {
+	s, _ := krawczyk.NewSplitter(4, 2)
+	r := csprng.NewCSPRNG()
+	b.SetBytes(int64(len(bytes1k)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = s.SplitWithRandomizer(bytes1k, r)
+	}
+}
+
 func BenchmarkSplitKrawczyk100NoDelay(t *testing.B) {
 	originalData := make([]byte, 100)
 	_, _ = rand.Read(originalData)
@@ -350,6 +372,55 @@ func BenchmarkSplitCombineKrawczyk10K(b *testing.B) {
 	}
 }
 
+func BenchmarkSplitAONTRS100(b *testing.B) {
+	b.SetBytes(int64(len(bytes100)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = aontrs.Split(bytes100, 4, 2)
+	}
+}
+
+func BenchmarkSplitAONTRS1K(b *testing.B) {
+	b.SetBytes(int64(len(bytes1k)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = aontrs.Split(bytes1k, 4, 2)
+	}
+}
+
+func BenchmarkSplitAONTRS10K(b *testing.B) {
+	b.SetBytes(int64(len(bytes10k)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = aontrs.Split(bytes10k, 4, 2)
+	}
+}
+
+func BenchmarkSplitKrawczyk1M(b *testing.B) {
+	b.SetBytes(int64(len(bytes1M)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = krawczyk.Split(bytes1M, 4, 2)
+	}
+}
+
+func BenchmarkSplitAONTRS1M(b *testing.B) {
+	b.SetBytes(int64(len(bytes1M)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = aontrs.Split(bytes1M, 4, 2)
+	}
+}
+
+func BenchmarkSplitCombineAONTRS10K(b *testing.B) {
+	b.SetBytes(int64(len(bytes10k)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ss, _ := aontrs.Split(bytes10k, 4, 2)
+		_, _ = aontrs.Combine(ss, 4, 2)
+	}
+}
+
 func BenchmarkSplitHashicorp100b(b *testing.B) {
 	b.SetBytes(int64(len(bytes100)))
 	b.ResetTimer()
@@ -950,25 +1021,16 @@ func TestThroughputShamir(t *testing.T) {
 
 	data := make([]byte, reqSize)
 	rand.Read(data)
-	input := make(chan []byte, 1_000)
-	output := make(chan [][]byte, 1_000)
-
-	for i := 0; i < numThreads; i++ {
-		go func() {
-			r := csprng.NewCSPRNG()
-			for in := range input {
-				res, _ := shamir.SplitWithRandomizer(in, 4, 2, r)
-				output <- res
-			}
-		}()
-	}
+	pool := worker.NewPool(numThreads, 1_000)
+	output := make(chan (<-chan worker.SplitResult), 1_000)
 
 	start := time.Now()
 	go func() {
 		for i := 0; i < numRequest; i++ {
 			in := make([]byte, 50)
 			copy(in, data)
-			input <- in
+			res, _ := pool.SubmitSplit(worker.AlgShamir, in, 4, 2)
+			output <- res
 		}
 	}()
 	wg := sync.WaitGroup{}
@@ -976,13 +1038,14 @@ func TestThroughputShamir(t *testing.T) {
 	go func() {
 		defer wg.Done()
 		for i := 0; i < numRequest; i++ {
-			<- output
+			<- <-output
 		}
 	}()
 	wg.Wait()
+	pool.Close()
 
 	dur := time.Since(start)
-	t.Log("#workers ", runtime.NumCPU())
+	t.Log("#workers ", numThreads)
 	t.Log("duration ", dur)
 	t.Log("throughput ", float64(numRequest)/dur.Seconds(), "req/s")
 }
@@ -1029,4 +1092,116 @@ func TestThroughputSSMS(t *testing.T) {
 	t.Log("#workers ", runtime.NumCPU())
 	t.Log("duration ", dur)
 	t.Log("throughput ", float64(numRequest)/dur.Seconds(), "req/s")
-}
\ No newline at end of file
+}
+
+func BenchmarkSplitShamir50x1K(b *testing.B) {
+	r := csprng.NewCSPRNG()
+	b.SetBytes(int64(50 * 1_000))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < 1_000; j++ {
+			_, _ = shamir.SplitWithRandomizer(bytes1k[j%950:j%950+50], 4, 2, r)
+		}
+	}
+}
+
+func BenchmarkSplitBatchShamir50x1K(b *testing.B) {
+	r := csprng.NewCSPRNG()
+	secrets := make([][]byte, 1_000)
+	for j := range secrets {
+		secrets[j] = bytes1k[j%950 : j%950+50]
+	}
+	b.SetBytes(int64(50 * 1_000))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = shamir.SplitBatchWithRandomizer(secrets, 4, 2, r)
+	}
+}
+
+func BenchmarkSplitBatchAtShamir50x1K(b *testing.B) {
+	r := csprng.NewCSPRNG()
+	secrets := make([][]byte, 1_000)
+	for j := range secrets {
+		secrets[j] = bytes1k[j%950 : j%950+50]
+	}
+	b.SetBytes(int64(50 * 1_000))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = shamir.SplitBatchAt(secrets, []byte{1, 2, 3, 4}, 2, r)
+	}
+}
+
+func BenchmarkCombineShamir1K(b *testing.B) {
+	shares, _ := shamir.Split(bytes1k, 4, 2)
+	b.SetBytes(int64(len(bytes1k)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = shamir.Combine(shares[:2])
+	}
+}
+
+func BenchmarkCombinerShamir1K(b *testing.B) {
+	shares, _ := shamir.SplitAt(bytes1k, []byte{1, 2, 3, 4}, 2, nil)
+	c, _ := shamir.NewCombiner([]byte{1, 2})
+	b.SetBytes(int64(len(bytes1k)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = c.Combine(shares[:2])
+	}
+}
+
+func BenchmarkSplitShamir100(b *testing.B) {
+	r := csprng.NewCSPRNG()
+	b.SetBytes(int64(len(bytes100)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = shamir.SplitWithRandomizer(bytes100, 4, 2, r)
+	}
+}
+
+func BenchmarkSplitterShamir100(b *testing.B) {
+	s, _ := shamir.NewSplitter(4, 2)
+	r := csprng.NewCSPRNG()
+	b.SetBytes(int64(len(bytes100)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = s.SplitWithRandomizer(bytes100, r)
+	}
+}
+
+func BenchmarkSplitShamir1M(b *testing.B) {
+	r := csprng.NewCSPRNG()
+	b.SetBytes(int64(len(bytes1M)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = shamir.SplitWithRandomizer(bytes1M, 4, 2, r)
+	}
+}
+
+func BenchmarkSplitterShamir1M(b *testing.B) {
+	s, _ := shamir.NewSplitter(4, 2)
+	r := csprng.NewCSPRNG()
+	b.SetBytes(int64(len(bytes1M)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = s.SplitWithRandomizer(bytes1M, r)
+	}
+}
+
+func BenchmarkSplitShamirP1M(b *testing.B) {
+	r := csprng.NewCSPRNG()
+	b.SetBytes(int64(len(bytes1M)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = shamir.SplitWithRandomizerP(bytes1M, 4, 2, r)
+	}
+}
+
+func BenchmarkCombineShamirP1M(b *testing.B) {
+	shares, _ := shamir.SplitP(bytes1M, 4, 2)
+	b.SetBytes(int64(len(bytes1M)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = shamir.CombineP(shares[:2])
+	}
+}
@@ -9,6 +9,9 @@ import (
 type CSPRNG struct {
 	c  *aesctrat.AesCtr
 	iv []byte
+
+	// offset is the position in the key stream of the next Read
+	offset uint64
 }
 
 func init() {
@@ -19,16 +22,22 @@ func NewCSPRNG() *CSPRNG {
 	keyIv := make([]byte, 32)
 	rand.Read(keyIv)
 	ctr := aesctrat.NewAesCtr(keyIv[:16])
-	return &CSPRNG{ctr, keyIv[16:]}
+	return &CSPRNG{c: ctr, iv: keyIv[16:]}
 }
 
 func NewCSPRNGWithKeyIV(keyIv []byte) *CSPRNG {
 	ctr := aesctrat.NewAesCtr(keyIv[:16])
-	return &CSPRNG{ctr, keyIv[16:]}
+	return &CSPRNG{c: ctr, iv: keyIv[16:]}
 }
 
+// Read fills buff with the next bytes of the key stream, consecutive
+// reads never return the same bytes. A CSPRNG is not safe for concurrent use.
 func (r *CSPRNG) Read(buff []byte) (int, error) {
-	r.c.XORKeyStreamAt(buff, buff, r.iv, 0)
+	for i := range buff {
+		buff[i] = 0
+	}
+	r.c.XORKeyStreamAt(buff, buff, r.iv, r.offset)
+	r.offset += uint64(len(buff))
 	return len(buff), nil
 }
 
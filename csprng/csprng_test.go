@@ -32,6 +32,27 @@ func TestRandomVsGo(t *testing.T) {
 	}
 }
 
+func TestReadAdvances(t *testing.T) {
+	keyIv := make([]byte, 32)
+	r1 := NewCSPRNGWithKeyIV(keyIv)
+	r2 := NewCSPRNGWithKeyIV(keyIv)
+
+	buff1 := make([]byte, 100)
+	_, _ = r1.Read(buff1[:37])
+	_, _ = r1.Read(buff1[37:])
+	buff2 := make([]byte, 100)
+	_, _ = r2.Read(buff2)
+	if bytes.Compare(buff1, buff2) != 0 {
+		t.Errorf("consecutive reads are not continuing the key stream, %x vs %x", buff1, buff2)
+	}
+
+	// reading again into the same buffer gives new bytes
+	_, _ = r1.Read(buff1)
+	if bytes.Compare(buff1, buff2) == 0 {
+		t.Errorf("a read returns the same bytes with the previous read, %x", buff1)
+	}
+}
+
 func TestPerm(t *testing.T) {
 	r := NewCSPRNG()
 	buff := r.Perm(255)
@@ -109,3 +109,41 @@ func BenchmarkGaloisMulSIMD1M(b *testing.B) {
 		MulConstVector(10, bytes100kClone)
 	}
 }
+
+func BenchmarkGaloisMulAddGeneric1M(b *testing.B) {
+	out := make([]byte, len(bytes1M))
+	b.SetBytes(int64(len(bytes1M)) * 2)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		MulAddVectorGeneric(10, bytes1M, out)
+	}
+}
+
+func BenchmarkGaloisMulAddSIMD1M(b *testing.B) {
+	out := make([]byte, len(bytes1M))
+	b.SetBytes(int64(len(bytes1M)) * 2)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		MulAddVector(10, bytes1M, out)
+	}
+}
+
+func BenchmarkGaloisMulAddCTGeneric1M(b *testing.B) {
+	out := make([]byte, len(bytes1M))
+	b.SetBytes(int64(len(bytes1M)) * 2)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		MulAddVectorCT(10, bytes1M, out)
+	}
+}
+
+func BenchmarkGaloisMulAddCTSIMD1M(b *testing.B) {
+	SetConstantTime(true)
+	defer SetConstantTime(false)
+	out := make([]byte, len(bytes1M))
+	b.SetBytes(int64(len(bytes1M)) * 2)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		MulAddVector(10, bytes1M, out)
+	}
+}
@@ -0,0 +1,127 @@
+package galois
+
+import (
+	"encoding/binary"
+	"sync/atomic"
+)
+
+// constantTime is set by SetConstantTime, see ConstantTime.
+var constantTime int32
+
+// SetConstantTime enables or disables the constant-time mode. In the
+// constant-time mode, the arithmetic never indexes tables nor branches on
+// the operands: the scalar multiplications are done bit by bit, the vector
+// multiplications run the SIMD shuffles with tables computed for the
+// constant, and the remaining bytes are multiplied 8 at a time within
+// 64-bit words. The mode can not be disabled when built with the
+// gfconsttime tag.
+func SetConstantTime(enabled bool) {
+	if enabled {
+		atomic.StoreInt32(&constantTime, 1)
+	} else {
+		atomic.StoreInt32(&constantTime, 0)
+	}
+}
+
+// ConstantTime reports whether the constant-time mode is enabled.
+func ConstantTime() bool {
+	return constantTimeBuild || atomic.LoadInt32(&constantTime) != 0
+}
+
+// GalMultiplyCT multiplies a and b in GF(2^8), in constant time.
+func GalMultiplyCT(a, b byte) byte {
+	var p byte
+	for i := 0; i < 8; i++ {
+		p ^= -(b & 1) & a
+		b >>= 1
+		// a *= x, reduced by the field polynomial 0x11d
+		a = (a << 1) ^ (-(a >> 7) & 0x1d)
+	}
+	return p
+}
+
+// GalInverseCT returns the multiplicative inverse of a in GF(2^8), in
+// constant time, as a^254. The inverse of 0 is 0.
+func GalInverseCT(a byte) byte {
+	// a^254 = a^2 * a^4 * .. * a^128
+	square := GalMultiplyCT(a, a)
+	inverse := square
+	for i := 0; i < 6; i++ {
+		square = GalMultiplyCT(square, square)
+		inverse = GalMultiplyCT(inverse, square)
+	}
+	return inverse
+}
+
+// GalDivideCT divides a by b in GF(2^8), in constant time.
+// The result is 0 when b is 0.
+func GalDivideCT(a, b byte) byte {
+	return GalMultiplyCT(a, GalInverseCT(b))
+}
+
+// GalExpCT computes a**n in constant time with respect to a,
+// n is assumed to be public.
+func GalExpCT(a byte, n int) byte {
+	result := byte(1)
+	for ; n > 0; n >>= 1 {
+		if n&1 == 1 {
+			result = GalMultiplyCT(result, a)
+		}
+		a = GalMultiplyCT(a, a)
+	}
+	return result
+}
+
+// mulConstWordCT multiplies each of the 8 bytes of x with c, in constant time.
+func mulConstWordCT(c byte, x uint64) uint64 {
+	const high = 0x8080808080808080
+	var p uint64
+	for i := 0; i < 8; i++ {
+		p ^= -uint64((c>>i)&1) & x
+		// each byte *= x, the reduction of a byte never carries to the next
+		x = ((x &^ high) << 1) ^ (((x & high) >> 7) * 0x1d)
+	}
+	return p
+}
+
+// mulTablesCT computes, in constant time, the products of c with the low
+// and the high nibbles, as used by the SIMD kernels.
+func mulTablesCT(c byte) (low, high [16]byte) {
+	for i := 0; i < 16; i++ {
+		low[i] = GalMultiplyCT(c, byte(i))
+		high[i] = GalMultiplyCT(c, byte(i<<4))
+	}
+	return low, high
+}
+
+// ctSIMDSwitchover is the vector size starting from which the SIMD kernels
+// are used in the constant-time mode, paying for computing their tables.
+const ctSIMDSwitchover = 64
+
+// MulAddVectorCT is MulAddVector in constant time, without the SIMD kernels.
+func MulAddVectorCT(c byte, in, out []byte) {
+	out = out[:len(in)]
+	for len(in) >= 8 {
+		p := mulConstWordCT(c, binary.LittleEndian.Uint64(in))
+		binary.LittleEndian.PutUint64(out, binary.LittleEndian.Uint64(out)^p)
+		in = in[8:]
+		out = out[8:]
+	}
+	for i := range in {
+		out[i] ^= GalMultiplyCT(c, in[i])
+	}
+}
+
+// mulConstVectorCT writes the products of c with in into out, in constant
+// time, without the SIMD kernels. in and out can be the same vector.
+func mulConstVectorCT(c byte, in, out []byte) {
+	out = out[:len(in)]
+	for len(in) >= 8 {
+		binary.LittleEndian.PutUint64(out, mulConstWordCT(c, binary.LittleEndian.Uint64(in)))
+		in = in[8:]
+		out = out[8:]
+	}
+	for i := range in {
+		out[i] = GalMultiplyCT(c, in[i])
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !gfconsttime
+// +build !gfconsttime
+
+package galois
+
+// constantTimeBuild is set with the gfconsttime build tag,
+// which always enables the constant-time mode.
+const constantTimeBuild = false
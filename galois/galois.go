@@ -14,12 +14,18 @@ func GalSub(a, b byte) byte {
 // Use GalMultiplyLogExp to do the same operation with less
 // memory.
 func GalMultiply(a, b byte) byte {
+	if ConstantTime() {
+		return GalMultiplyCT(a, b)
+	}
 	return mulTable[a][b]
 }
 
 // GalMultiplyLogExp multiplies two elements a and b in GF(2^8)
 // using log and exp tables.
 func GalMultiplyLogExp(a, b byte) byte {
+	if ConstantTime() {
+		return GalMultiplyCT(a, b)
+	}
 	if a == 0 || b == 0 {
 		return 0
 	}
@@ -31,12 +37,15 @@ func GalMultiplyLogExp(a, b byte) byte {
 // GalDivide is the inverse of GalMultiply, dividing element a by b
 // in GF(2^8).
 func GalDivide(a, b byte) byte {
-	if a == 0 {
+	if a == 0 && !ConstantTime() {
 		return 0
 	}
 	if b == 0 {
 		panic("Argument 'divisor' is 0")
 	}
+	if ConstantTime() {
+		return GalDivideCT(a, b)
+	}
 	logA := int(logTable[a])
 	logB := int(logTable[b])
 	logResult := logA - logB
@@ -49,6 +58,9 @@ func GalDivide(a, b byte) byte {
 // GalExp computes a**n.
 // The result will be the same as multiplying a times itself n times.
 func GalExp(a byte, n int) byte {
+	if ConstantTime() {
+		return GalExpCT(a, n)
+	}
 	if n == 0 {
 		return 1
 	}
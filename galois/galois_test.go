@@ -0,0 +1,159 @@
+package galois
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestGalMultiplyCT(t *testing.T) {
+	for a := 0; a < 256; a++ {
+		for b := 0; b < 256; b++ {
+			if p := GalMultiplyCT(byte(a), byte(b)); p != mulTable[a][b] {
+				t.Fatalf("expecting %d*%d=%d, but got %d", a, b, mulTable[a][b], p)
+			}
+			if b != 0 {
+				if q := GalDivideCT(byte(a), byte(b)); q != GalDivide(byte(a), byte(b)) {
+					t.Fatalf("expecting %d/%d=%d, but got %d", a, b, GalDivide(byte(a), byte(b)), q)
+				}
+			}
+		}
+		for _, n := range []int{0, 1, 2, 3, 254, 255, 256, 1000} {
+			if e := GalExpCT(byte(a), n); e != GalExp(byte(a), n) {
+				t.Fatalf("expecting %d**%d=%d, but got %d", a, n, GalExp(byte(a), n), e)
+			}
+		}
+	}
+	if GalInverseCT(0) != 0 {
+		t.Errorf("expecting the inverse of 0 to be 0")
+	}
+}
+
+func TestConstantTimeVector(t *testing.T) {
+	defer SetConstantTime(false)
+
+	for _, c := range []byte{0, 1, 2, 0x1d, 0x80, 0xff} {
+		for n := 0; n < 300; n++ {
+			in := make([]byte, n)
+			out := make([]byte, n)
+			rand.Read(in)
+			rand.Read(out)
+			expected := append([]byte(nil), out...)
+			for i := range in {
+				expected[i] ^= mulTable[c][in[i]]
+			}
+
+			SetConstantTime(true)
+			MulAddVector(c, in, out)
+			product := MulConstVector(c, in)
+			generic := MulConstVectorGeneric(c, append([]byte(nil), in...))
+			SetConstantTime(false)
+			if !bytes.Equal(expected, out) {
+				t.Fatalf("MulAddVector(%d) of %d bytes in the constant-time mode is wrong", c, n)
+			}
+			if !bytes.Equal(MulConstVector(c, in), product) || !bytes.Equal(product, generic) {
+				t.Fatalf("MulConstVector(%d) of %d bytes in the constant-time mode is wrong", c, n)
+			}
+		}
+	}
+}
+
+// welchT returns the Welch's t statistic of the two samples.
+func welchT(a, b []float64) float64 {
+	meanVar := func(x []float64) (float64, float64) {
+		var mean, m2 float64
+		for i, v := range x {
+			d := v - mean
+			mean += d / float64(i+1)
+			m2 += d * (v - mean)
+		}
+		return mean, m2 / float64(len(x)-1)
+	}
+	meanA, varA := meanVar(a)
+	meanB, varB := meanVar(b)
+	return (meanA - meanB) / math.Sqrt(varA/float64(len(a))+varB/float64(len(b)))
+}
+
+// leakage measures f as in dudect: the input is either a fixed input
+// (class 0) or a random input (class 1), chosen at random for each
+// measurement, and the timings of the classes are compared with Welch's
+// t-test, after cropping the slowest measurements caused by the noise of
+// the host. It returns the largest |t| over a few cropping percentiles.
+func leakage(fixed []byte, f func(in []byte)) float64 {
+	const measurements = 20000
+	const repeat = 32
+	inputs := [2][]byte{fixed, make([]byte, len(fixed))}
+	timings := make([]float64, measurements)
+	classes := make([]int, measurements)
+	for i := range timings {
+		classes[i] = rand.Intn(2)
+		if classes[i] == 1 {
+			rand.Read(inputs[1])
+		}
+		in := inputs[classes[i]]
+		start := time.Now()
+		for r := 0; r < repeat; r++ {
+			f(in)
+		}
+		timings[i] = float64(time.Since(start))
+	}
+
+	sorted := append([]float64(nil), timings...)
+	sort.Float64s(sorted)
+	maxT := 0.0
+	for _, percentile := range []float64{0.5, 0.75, 0.9} {
+		threshold := sorted[int(percentile*float64(len(sorted)))]
+		var samples [2][]float64
+		for i, v := range timings {
+			if v <= threshold {
+				samples[classes[i]] = append(samples[classes[i]], v)
+			}
+		}
+		maxT = math.Max(maxT, math.Abs(welchT(samples[0], samples[1])))
+	}
+	return maxT
+}
+
+func TestConstantTimeWelch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping the timing test in short mode")
+	}
+	SetConstantTime(true)
+	defer SetConstantTime(false)
+
+	// |t| above 10 is a definite leak, see "Dude, is my code constant time?",
+	// a leak must be seen in every attempt to rule out the noise of the host
+	const maxT = 10
+	const attempts = 3
+	out := make([]byte, 1000)
+	cases := []struct {
+		name string
+		f    func(in []byte)
+	}{
+		{"GalMultiply", func(in []byte) {
+			out[0] ^= GalMultiply(in[0], in[1]) ^ GalDivide(in[2], in[3]|1)
+		}},
+		{"MulAddVector-tail", func(in []byte) {
+			MulAddVector(in[0], in[1:40], out)
+		}},
+		{"MulAddVector-SIMD", func(in []byte) {
+			MulAddVector(in[0], in[1:], out)
+		}},
+	}
+	for _, c := range cases {
+		tStat := 0.0
+		for i := 0; i < attempts; i++ {
+			if tStat = leakage(make([]byte, 1000), c.f); tStat <= maxT {
+				break
+			}
+		}
+		if tStat > maxT {
+			t.Errorf("%s: the timings depend on the input, |t|=%.2f", c.name, tStat)
+		} else {
+			t.Logf("%s: |t|=%.2f", c.name, tStat)
+		}
+	}
+}
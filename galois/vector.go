@@ -16,3 +16,9 @@ func AddVector(a, b []byte) []byte {
 func MulConstVector(c byte, a []byte) []byte {
 	return MulConstVectorGeneric(c, a)
 }
+
+// MulAddVector multiplies all elements in vector in with constant c, then
+// adds the results into vector out, using GF(2^8) arithmetic
+func MulAddVector(c byte, in, out []byte) {
+	MulAddVectorGeneric(c, in, out)
+}
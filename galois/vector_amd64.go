@@ -69,6 +69,10 @@ func MulConstVector(c byte, in []byte) []byte {
 	out := make([]byte, len(in))
 	origOutPointer := out
 
+	if ConstantTime() {
+		mulAddVectorCT(c, in, out)
+		return origOutPointer
+	}
 	if c == 1 {
 		copy(out, in)
 		return origOutPointer
@@ -103,6 +107,63 @@ func MulConstVector(c byte, in []byte) []byte {
 	return origOutPointer
 }
 
+// MulAddVector multiplies all elements in vector in with constant c, then
+// adds the results into vector out, using GF(2^8) arithmetic
+func MulAddVector(c byte, in, out []byte) {
+	if ConstantTime() {
+		mulAddVectorCT(c, in, out)
+		return
+	}
+	if c == 0 {
+		return
+	}
+	if c == 1 {
+		AddVector(in, out)
+		return
+	}
+	done := galMulXorSIMD(mulTableLow[c][:], mulTableHigh[c][:], in, out)
+	in = in[done:]
+	out = out[done:]
+	out = out[:len(in)]
+	mt := mulTable[c][:256]
+	for i := range in {
+		out[i] ^= mt[in[i]]
+	}
+}
+
+// galMulXorSIMD runs the SIMD kernel multiplying with the constant of the
+// low and high tables, xoring into out, and returns the number of bytes done.
+func galMulXorSIMD(low, high, in, out []byte) int {
+	done := 0
+	if useAVX2 {
+		if len(in) >= bigSwitchover {
+			galMulAVX2Xor_64(low, high, in, out)
+			done = (len(in) >> 6) << 6
+		}
+		if len(in)-done > 32 {
+			galMulAVX2Xor(low, high, in[done:], out[done:])
+			done += ((len(in) - done) >> 5) << 5
+		}
+	} else if useSSSE3 {
+		galMulSSSE3Xor(low, high, in, out)
+		done = (len(in) >> 4) << 4
+	}
+	return done
+}
+
+// mulAddVectorCT is MulAddVector in the constant-time mode, the tables of
+// the SIMD kernels are computed for c rather than looked up. The shuffles
+// of the kernels do not access memory depending on the input.
+func mulAddVectorCT(c byte, in, out []byte) {
+	if len(in) >= ctSIMDSwitchover {
+		low, high := mulTablesCT(c)
+		done := galMulXorSIMD(low[:], high[:], in, out)
+		in = in[done:]
+		out = out[done:]
+	}
+	MulAddVectorCT(c, in, out)
+}
+
 // simple slice xor
 func AddVector(in, out []byte) []byte {
 	origOutPointer := out
@@ -17,6 +17,10 @@ func galXorNEON(in, out []byte)
 func MulConstVector(c byte, in []byte) []byte {
 	out := make([]byte, len(in))
 
+	if ConstantTime() {
+		mulAddVectorCT(c, in, out)
+		return out
+	}
 	if c == 1 {
 		copy(out, in)
 		return out
@@ -36,6 +40,43 @@ func MulConstVector(c byte, in []byte) []byte {
 	return out
 }
 
+// MulAddVector multiplies all elements in vector in with constant c, then
+// adds the results into vector out, using GF(2^8) arithmetic
+func MulAddVector(c byte, in, out []byte) {
+	if ConstantTime() {
+		mulAddVectorCT(c, in, out)
+		return
+	}
+	if c == 0 {
+		return
+	}
+	if c == 1 {
+		AddVector(in, out)
+		return
+	}
+	galMulXorNEON(mulTableLow[c][:], mulTableHigh[c][:], in, out)
+	done := (len(in) >> 5) << 5
+
+	mt := mulTable[c][:256]
+	for i := done; i < len(in); i++ {
+		out[i] ^= mt[in[i]]
+	}
+}
+
+// mulAddVectorCT is MulAddVector in the constant-time mode, the tables of
+// the NEON kernel are computed for c rather than looked up. The table
+// lookups of the kernel do not access memory depending on the input.
+func mulAddVectorCT(c byte, in, out []byte) {
+	if len(in) >= ctSIMDSwitchover {
+		low, high := mulTablesCT(c)
+		galMulXorNEON(low[:], high[:], in, out)
+		done := (len(in) >> 5) << 5
+		in = in[done:]
+		out = out[done:]
+	}
+	MulAddVectorCT(c, in, out)
+}
+
 // simple slice xor
 func AddVector(in, out []byte) []byte {
 	origOutPointer := out
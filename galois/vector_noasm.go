@@ -27,12 +27,27 @@ func AddVectorGeneric(a, b []byte) []byte {
 // MulConstVector multiply all elements in vector a with constant c using GF(2^8) arithmetic
 // Warning: the result returned is replacing the original vector a
 func MulConstVectorGeneric(c byte, a []byte) []byte {
+	if ConstantTime() {
+		mulConstVectorCT(c, a, a)
+		return a
+	}
 	for idx, val := range a {
 		a[idx] = GalMultiply(val, c)
 	}
 	return a
 }
 
+// MulAddVectorGeneric multiplies all elements in vector in with constant c, then
+// adds the results into vector out, using GF(2^8) arithmetic
+func MulAddVectorGeneric(c byte, in, out []byte) {
+	if ConstantTime() {
+		MulAddVectorCT(c, in, out)
+		return
+	}
+	for idx, val := range in {
+		out[idx] ^= GalMultiply(val, c)
+	}
+}
 
 // AddVectorBatch .
 func AddVectorBatchGeneric(a, b []byte) []byte {
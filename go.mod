@@ -8,3 +8,8 @@ require (
 	github.com/klauspost/reedsolomon v1.9.15
 	github.com/starius/aesctrat v0.0.0-20220106011601-da278dad2aaa
 )
+
+require (
+	golang.org/x/crypto v0.0.0-20220208050332-20e1d8d225ab
+	golang.org/x/sys v0.0.0-20220207234003-57398862261d
+)
@@ -0,0 +1,396 @@
+// Package hierarchical implements Tassa's hierarchical threshold
+// secret-sharing ("Hierarchical Threshold Secret Sharing", J. Cryptology
+// 2007) over GF(2^8). The participants are split into levels, each with a
+// cumulative threshold: the secret is recovered by `Threshold` shares of
+// the last level, of which at least `Threshold` of level i come from the
+// levels 0..i. For example, the levels
+//
+//	[]Level{{Parts: 2, Threshold: 1}, {Parts: 5, Threshold: 3}}
+//
+// require at least one share of level 0 and three shares overall.
+//
+// The secret is the intercept of a random polynomial f of degree t-1, t
+// being the last threshold. A share of level i is f^(k)(x) with k the
+// threshold of level i-1 (0 for level 0), so only the levels above know
+// the low coefficients. Tassa uses the k-th derivative, which vanishes in
+// characteristic 2, so instead f^(k) is the shifted polynomial
+// sum_{j>=k} a_j x^(j-k), and recovering the secret is the Birkhoff
+// interpolation of the shares. Some x coordinates make the Birkhoff matrix
+// of an authorized set singular, so Split checks the matrix of every
+// minimal authorized set.
+package hierarchical
+
+import (
+	"errors"
+	"fmt"
+	"github.com/fadhilkurnia/shamir/csprng"
+	gf "github.com/fadhilkurnia/shamir/galois"
+	"github.com/fadhilkurnia/shamir/utils"
+	"math/rand"
+)
+
+// ShareOverhead is the byte size of the trailer of a share: the last
+// threshold, the order of the shifted polynomial, and the x coordinate.
+const ShareOverhead = 3
+
+// maxChecks is the number of minimal authorized sets whose Birkhoff
+// matrices are checked by Split.
+const maxChecks = 1 << 20
+
+// xAttempts is the number of random x coordinates tried by Split when the
+// default ones give a singular Birkhoff matrix.
+const xAttempts = 32
+
+// Level is a level of the hierarchy, level 0 being the highest.
+type Level struct {
+	// Parts is the number of shares of the level
+	Parts int
+	// Threshold is the number of shares required from the level and the levels above
+	Threshold int
+}
+
+// ErrSingular is returned when the Birkhoff matrix of an authorized set
+// of shares is singular, so the set cannot recover the secret.
+var ErrSingular = errors.New("the birkhoff matrix of an authorized set is singular")
+
+// Split splits the secret into the shares of the levels, the shares of
+// level 0 first. The x coordinates are 1, 2, .. unless their Birkhoff
+// matrices are singular, then random ones are tried.
+func Split(secret []byte, levels []Level) ([][]byte, error) {
+	return SplitWithRandomizer(secret, levels, nil)
+}
+
+// SplitWithRandomizer is exactly the same with Split but with randomizer
+// provided by the caller. When randomizer is nil, math/rand is used.
+func SplitWithRandomizer(secret []byte, levels []Level, randomizer *csprng.CSPRNG) ([][]byte, error) {
+	orders, err := checkLevels(levels)
+	if err != nil {
+		return nil, err
+	}
+	xCoordinates := make([]byte, len(orders))
+	for i := range xCoordinates {
+		xCoordinates[i] = byte(i + 1)
+	}
+	threshold := levels[len(levels)-1].Threshold
+	for attempt := 0; ; attempt++ {
+		err = checkInvertible(levels, orders, xCoordinates)
+		if err != ErrSingular || attempt == xAttempts {
+			break
+		}
+		if err = randomXCoordinates(xCoordinates, randomizer); err != nil {
+			return nil, err
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return split(secret, threshold, orders, xCoordinates, randomizer)
+}
+
+// SplitAt is the same with SplitWithRandomizer but with the x coordinates
+// of the shares given by the caller, ErrSingular is returned when they
+// give a singular Birkhoff matrix.
+func SplitAt(secret []byte, levels []Level, xCoordinates []byte, randomizer *csprng.CSPRNG) ([][]byte, error) {
+	orders, err := checkLevels(levels)
+	if err != nil {
+		return nil, err
+	}
+	if len(xCoordinates) != len(orders) {
+		return nil, fmt.Errorf("expecting %d x coordinates, but got %d", len(orders), len(xCoordinates))
+	}
+	seen := map[byte]bool{}
+	for _, x := range xCoordinates {
+		if x == 0 {
+			return nil, fmt.Errorf("x coordinate cannot be zero")
+		}
+		if seen[x] {
+			return nil, fmt.Errorf("duplicate x coordinate %d", x)
+		}
+		seen[x] = true
+	}
+	if err := checkInvertible(levels, orders, xCoordinates); err != nil {
+		return nil, err
+	}
+	return split(secret, levels[len(levels)-1].Threshold, orders, xCoordinates, randomizer)
+}
+
+// checkLevels checks the levels, and returns the order of the shifted
+// polynomial of each share.
+func checkLevels(levels []Level) ([]int, error) {
+	if len(levels) == 0 {
+		return nil, fmt.Errorf("at least one level is required")
+	}
+	parts, previous := 0, 0
+	for i, level := range levels {
+		if level.Parts < 0 {
+			return nil, fmt.Errorf("level %d cannot have negative parts", i)
+		}
+		if level.Threshold <= previous {
+			return nil, fmt.Errorf("the threshold of level %d must be larger than %d", i, previous)
+		}
+		parts += level.Parts
+		if level.Threshold > parts {
+			return nil, fmt.Errorf("the threshold of level %d cannot exceed the %d parts up to the level", i, parts)
+		}
+		previous = level.Threshold
+	}
+	if parts > 255 {
+		return nil, fmt.Errorf("parts cannot exceed 255")
+	}
+	if previous > 255 {
+		return nil, fmt.Errorf("threshold cannot exceed 255")
+	}
+
+	orders := make([]int, 0, parts)
+	order := 0
+	for _, level := range levels {
+		for p := 0; p < level.Parts; p++ {
+			orders = append(orders, order)
+		}
+		order = level.Threshold
+	}
+	return orders, nil
+}
+
+// randomXCoordinates fills xCoordinates with distinct non-zero random bytes.
+func randomXCoordinates(xCoordinates []byte, randomizer *csprng.CSPRNG) error {
+	var shuffler [255]byte
+	var err error
+	if randomizer != nil {
+		_, err = randomizer.Read(shuffler[:])
+	} else {
+		_, err = rand.Read(shuffler[:])
+	}
+	if err != nil {
+		return fmt.Errorf("failed to generate the x coordinates: %v", err)
+	}
+	var candidates [255]byte
+	for i := range candidates {
+		candidates[i] = byte(i + 1)
+	}
+	for j := range xCoordinates {
+		k := j + int(shuffler[j])%(len(candidates)-j)
+		candidates[j], candidates[k] = candidates[k], candidates[j]
+	}
+	copy(xCoordinates, candidates[:len(xCoordinates)])
+	return nil
+}
+
+// birkhoffRow returns the row of the Birkhoff matrix of a share: the
+// coefficients of a_0..a_{t-1} in sum_{j>=order} a_j x^(j-order).
+func birkhoffRow(threshold, order int, x byte) []byte {
+	row := make([]byte, threshold)
+	xPow := byte(1)
+	for j := order; j < threshold; j++ {
+		row[j] = xPow
+		xPow = gf.GalMultiply(xPow, x)
+	}
+	return row
+}
+
+func split(secret []byte, threshold int, orders []int, xCoordinates []byte, randomizer *csprng.CSPRNG) ([][]byte, error) {
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("cannot split an empty secret")
+	}
+
+	// coefficients[j*N:(j+1)*N] is a_j of the N polynomials, a_0 is the secret
+	N := len(secret)
+	coefficients := make([]byte, threshold*N)
+	defer utils.Wipe(coefficients)
+	var err error
+	if randomizer != nil {
+		_, err = randomizer.Read(coefficients[N:])
+	} else {
+		_, err = rand.Read(coefficients[N:])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate the coefficients: %v", err)
+	}
+	copy(coefficients, secret)
+
+	shares := make([][]byte, len(orders))
+	for i, order := range orders {
+		share := make([]byte, N+ShareOverhead)
+		row := birkhoffRow(threshold, order, xCoordinates[i])
+		for j := order; j < threshold; j++ {
+			gf.MulAddVector(row[j], coefficients[j*N:(j+1)*N], share[:N])
+		}
+		share[N] = byte(threshold)
+		share[N+1] = byte(order)
+		share[N+2] = xCoordinates[i]
+		shares[i] = share
+	}
+	return shares, nil
+}
+
+// Combine recovers the secret from the shares, which must satisfy the
+// thresholds of the levels.
+func Combine(parts [][]byte) ([]byte, error) {
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("no part is given")
+	}
+	partLen := len(parts[0])
+	if partLen <= ShareOverhead {
+		return nil, fmt.Errorf("parts must be at least %d bytes", ShareOverhead+1)
+	}
+	N := partLen - ShareOverhead
+	threshold := int(parts[0][N])
+	if threshold == 0 {
+		return nil, fmt.Errorf("invalid threshold in the parts")
+	}
+
+	seen := map[byte]bool{}
+	rows := make([][]byte, 0, threshold)
+	selected := make([][]byte, 0, threshold)
+	var basis echelon
+	for _, part := range parts {
+		if len(part) != partLen {
+			return nil, fmt.Errorf("all parts must be the same length")
+		}
+		if int(part[N]) != threshold {
+			return nil, fmt.Errorf("all parts must be split with the same threshold")
+		}
+		order, x := int(part[N+1]), part[N+2]
+		if order >= threshold {
+			return nil, fmt.Errorf("invalid order %d in a part", order)
+		}
+		if seen[x] {
+			return nil, fmt.Errorf("duplicate part detected")
+		}
+		seen[x] = true
+
+		// keep the first linearly independent rows
+		if len(rows) == threshold {
+			continue
+		}
+		row := birkhoffRow(threshold, order, x)
+		if basis.add(row) {
+			rows = append(rows, row)
+			selected = append(selected, part[:N])
+		}
+	}
+	if len(rows) < threshold {
+		return nil, fmt.Errorf("the parts do not satisfy the thresholds of the levels, or their birkhoff matrix is singular")
+	}
+
+	// the secret is a_0, the first row of the inverse matrix gives
+	// its weights in the selected shares
+	inverse, ok := invert(rows)
+	if !ok {
+		return nil, ErrSingular
+	}
+	secret := make([]byte, N)
+	for i, y := range selected {
+		gf.MulAddVector(inverse[0][i], y, secret)
+	}
+	return secret, nil
+}
+
+// checkInvertible checks the Birkhoff matrix of every minimal authorized
+// set: threshold shares with, for every level i, at least the threshold
+// of level i from the levels 0..i.
+func checkInvertible(levels []Level, orders []int, xCoordinates []byte) error {
+	threshold := levels[len(levels)-1].Threshold
+	rows := make([][]byte, len(orders))
+	for i, order := range orders {
+		rows[i] = birkhoffRow(threshold, order, xCoordinates[i])
+	}
+
+	// walk the sets in lexicographic order, the shares are sorted by level,
+	// so a share of order k can be chosen once k shares are chosen
+	checks := 0
+	var basis echelon
+	var walk func(from, depth int, singular bool) error
+	walk = func(from, depth int, singular bool) error {
+		if depth == threshold {
+			if singular {
+				return ErrSingular
+			}
+			checks++
+			if checks > maxChecks {
+				return fmt.Errorf("more than %d authorized sets to check", maxChecks)
+			}
+			return nil
+		}
+		for i := from; i <= len(rows)-(threshold-depth); i++ {
+			if orders[i] > depth {
+				break
+			}
+			independent := singular || basis.add(rows[i])
+			if err := walk(i+1, depth+1, singular || !independent); err != nil {
+				return err
+			}
+			if !singular && independent {
+				basis.pop()
+			}
+		}
+		return nil
+	}
+	return walk(0, 0, false)
+}
+
+// echelon is a set of linearly independent rows in row echelon form.
+type echelon struct {
+	rows   [][]byte
+	pivots []int
+}
+
+// add reduces the row with the rows of the echelon, and adds it when it
+// is linearly independent of them.
+func (e *echelon) add(row []byte) bool {
+	row = append([]byte(nil), row...)
+	for i, r := range e.rows {
+		if c := row[e.pivots[i]]; c != 0 {
+			gf.MulAddVector(gf.GalDivide(c, r[e.pivots[i]]), r, row)
+		}
+	}
+	for pivot, c := range row {
+		if c != 0 {
+			e.rows = append(e.rows, row)
+			e.pivots = append(e.pivots, pivot)
+			return true
+		}
+	}
+	return false
+}
+
+// pop removes the last added row.
+func (e *echelon) pop() {
+	e.rows = e.rows[:len(e.rows)-1]
+	e.pivots = e.pivots[:len(e.pivots)-1]
+}
+
+// invert returns the inverse of the square matrix with Gauss-Jordan
+// elimination, ok is false when the matrix is singular.
+func invert(matrix [][]byte) (inverse [][]byte, ok bool) {
+	n := len(matrix)
+	work := make([][]byte, n)
+	inverse = make([][]byte, n)
+	for i := range matrix {
+		work[i] = append([]byte(nil), matrix[i]...)
+		inverse[i] = make([]byte, n)
+		inverse[i][i] = 1
+	}
+	for col := 0; col < n; col++ {
+		pivot := col
+		for pivot < n && work[pivot][col] == 0 {
+			pivot++
+		}
+		if pivot == n {
+			return nil, false
+		}
+		work[col], work[pivot] = work[pivot], work[col]
+		inverse[col], inverse[pivot] = inverse[pivot], inverse[col]
+
+		scale := gf.GalDivide(1, work[col][col])
+		work[col] = gf.MulConstVector(scale, work[col])
+		inverse[col] = gf.MulConstVector(scale, inverse[col])
+		for r := 0; r < n; r++ {
+			if c := work[r][col]; r != col && c != 0 {
+				gf.MulAddVector(c, work[col], work[r])
+				gf.MulAddVector(c, inverse[col], inverse[r])
+			}
+		}
+	}
+	return inverse, true
+}
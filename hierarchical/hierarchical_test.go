@@ -0,0 +1,125 @@
+package hierarchical
+
+import (
+	"github.com/fadhilkurnia/shamir/csprng"
+	"reflect"
+	"testing"
+)
+
+// authorized reports whether the shares of the levels at the given
+// indexes satisfy the thresholds.
+func authorized(levels []Level, shareLevels []int, indexes []int) bool {
+	for l, level := range levels {
+		count := 0
+		for _, i := range indexes {
+			if shareLevels[i] <= l {
+				count++
+			}
+		}
+		if count < level.Threshold {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSplitCombine(t *testing.T) {
+	secretMsg := []byte("The quick brown fox jumps over the lazy dog")
+	randomizer := csprng.NewCSPRNG()
+
+	for _, levels := range [][]Level{
+		{{Parts: 2, Threshold: 1}, {Parts: 5, Threshold: 3}},
+		{{Parts: 3, Threshold: 2}, {Parts: 3, Threshold: 3}, {Parts: 4, Threshold: 5}},
+		{{Parts: 4, Threshold: 3}},
+		{{Parts: 1, Threshold: 1}, {Parts: 1, Threshold: 2}, {Parts: 6, Threshold: 4}},
+		{{Parts: 2, Threshold: 1}, {Parts: 1, Threshold: 3}},
+	} {
+		shares, err := SplitWithRandomizer(secretMsg, levels, randomizer)
+		if err != nil {
+			t.Fatalf("failed to split with levels %v: %v", levels, err)
+		}
+		var shareLevels []int
+		for l, level := range levels {
+			for p := 0; p < level.Parts; p++ {
+				shareLevels = append(shareLevels, l)
+			}
+		}
+		if len(shares) != len(shareLevels) {
+			t.Fatalf("expecting %d shares, but got %d", len(shareLevels), len(shares))
+		}
+
+		// try all the subsets of the shares
+		for set := 1; set < 1<<len(shares); set++ {
+			var indexes []int
+			var parts [][]byte
+			for i := range shares {
+				if set&(1<<i) != 0 {
+					indexes = append(indexes, i)
+					parts = append(parts, shares[i])
+				}
+			}
+			combined, err := Combine(parts)
+			if !authorized(levels, shareLevels, indexes) {
+				if err == nil {
+					t.Errorf("expecting an error when combining the shares %v of the levels %v", indexes, levels)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("failed to combine the shares %v of the levels %v: %v", indexes, levels, err)
+			}
+			if !reflect.DeepEqual(secretMsg, combined) {
+				t.Errorf("The combined secret is different. Expected: '%v', but got '%v'.\n", string(secretMsg), string(combined))
+			}
+		}
+	}
+}
+
+func TestSplitAtSingular(t *testing.T) {
+	secretMsg := []byte("The quick brown fox jumps over the lazy dog")
+	levels := []Level{{Parts: 2, Threshold: 1}, {Parts: 1, Threshold: 3}}
+
+	// the rows (1, a, a^2), (1, b, b^2) and (0, 1, c) have the determinant
+	// (a + b)(a + b + c), which is zero for c = a + b
+	if _, err := SplitAt(secretMsg, levels, []byte{1, 2, 3}, nil); err != ErrSingular {
+		t.Errorf("expecting ErrSingular, but got %v", err)
+	}
+	shares, err := SplitAt(secretMsg, levels, []byte{1, 2, 4}, nil)
+	if err != nil {
+		t.Fatalf("failed to split the message: %v", err)
+	}
+	combined, err := Combine(shares)
+	if err != nil {
+		t.Fatalf("failed to combine the message: %v", err)
+	}
+	if !reflect.DeepEqual(secretMsg, combined) {
+		t.Errorf("The combined secret is different. Expected: '%v', but got '%v'.\n", string(secretMsg), string(combined))
+	}
+
+	// Split falls back to random x coordinates
+	shares, err = Split(secretMsg, levels)
+	if err != nil {
+		t.Fatalf("failed to split the message: %v", err)
+	}
+	if shares[2][len(shares[2])-1] == shares[0][len(shares[0])-1]^shares[1][len(shares[1])-1] {
+		t.Errorf("expecting x coordinates with an invertible birkhoff matrix")
+	}
+}
+
+func TestInvalidLevels(t *testing.T) {
+	secretMsg := []byte("The quick brown fox jumps over the lazy dog")
+	for _, levels := range [][]Level{
+		nil,
+		{{Parts: 2, Threshold: 0}},
+		{{Parts: 2, Threshold: 3}},
+		{{Parts: 2, Threshold: 2}, {Parts: 2, Threshold: 2}},
+		{{Parts: 200, Threshold: 2}, {Parts: 100, Threshold: 3}},
+	} {
+		if _, err := Split(secretMsg, levels); err == nil {
+			t.Errorf("expecting an error when splitting with levels %v", levels)
+		}
+	}
+	if _, err := Split(nil, []Level{{Parts: 2, Threshold: 1}}); err == nil {
+		t.Errorf("expecting an error when splitting an empty secret")
+	}
+}
@@ -7,14 +7,23 @@ import (
 	"io"
 )
 
-func encrypt(plaintext, key []byte) ([]byte, error) {
+// newStream returns the AES-OFB key stream used to encrypt the secret.
+// The IV is always zero since every secret is encrypted with a fresh key.
+func newStream(key []byte) (cipher.Stream, error) {
 	block, err := aes.NewCipher(key)
 	if err != nil {
 		return nil, err
 	}
 
 	var iv [aes.BlockSize]byte
-	stream := cipher.NewOFB(block, iv[:])
+	return cipher.NewOFB(block, iv[:]), nil
+}
+
+func encrypt(plaintext, key []byte) ([]byte, error) {
+	stream, err := newStream(key)
+	if err != nil {
+		return nil, err
+	}
 
 	var out bytes.Buffer
 	writer := &cipher.StreamWriter{S: stream, W: &out}
@@ -26,14 +35,11 @@ func encrypt(plaintext, key []byte) ([]byte, error) {
 }
 
 func decrypt(ciphertext, key []byte) ([]byte, error) {
-	block, err := aes.NewCipher(key)
+	stream, err := newStream(key)
 	if err != nil {
 		return nil, err
 	}
 
-	var iv [aes.BlockSize]byte
-	stream := cipher.NewOFB(block, iv[:])
-
 	var out bytes.Buffer
 	reader := &cipher.StreamReader{S: stream, R: bytes.NewReader(ciphertext)}
 	if _, err := io.Copy(&out, reader); err != nil {
@@ -0,0 +1,121 @@
+package krawczyk
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+)
+
+// LenFingerprint is the size of the fingerprint of each share, the
+// SHA-256 digest of the share excluding the fingerprints.
+const LenFingerprint = sha256.Size
+
+// fingerprint hashes the share excluding the fingerprints section,
+// that is the header, the metadata share, the reed-solomon shard, and the part-id.
+func (h header) fingerprint(share []byte) []byte {
+	d := sha256.New()
+	d.Write(share[:h.len()+h.lenMetadata()])
+	d.Write(share[h.shardOffset():])
+	return d.Sum(nil)
+}
+
+// fingerprintSection returns the fingerprints of all the parts carried by a share.
+func (h header) fingerprintSection(share []byte) []byte {
+	return share[h.len()+h.lenMetadata() : h.shardOffset()]
+}
+
+// setFingerprints puts the fingerprints of all the shares in every share.
+func (h header) setFingerprints(shares [][]byte) {
+	fingerprints := make([]byte, 0, h.parts*LenFingerprint)
+	for i := 0; i < h.parts; i++ {
+		fingerprints = append(fingerprints, h.fingerprint(shares[i])...)
+	}
+	for i := 0; i < h.parts; i++ {
+		copy(h.fingerprintSection(shares[i]), fingerprints)
+	}
+}
+
+// verify picks the fingerprints agreed by the largest number of shares,
+// where each of those shares also matches its own fingerprint, then drops
+// the shares that do not match the agreed fingerprints. This tolerates
+// corrupted shares as long as the consistent shares outnumber them and
+// at least `threshold` consistent shares are given. verify returns the
+// consistent shares, one for each part, and the part-ids of the dropped ones.
+func (h header) verify(ssData [][]byte) ([][]byte, []int, error) {
+	// the shares with unexpected header or length are never consistent
+	raw := h.bytes()
+	lenShare := 0
+	counts := map[int]int{}
+	for i := 0; i < len(ssData); i++ {
+		counts[len(ssData[i])]++
+		if counts[len(ssData[i])] > counts[lenShare] {
+			lenShare = len(ssData[i])
+		}
+	}
+	partIDs := make([]int, len(ssData))
+	ownFingerprints := make([][]byte, len(ssData))
+	for i := 0; i < len(ssData); i++ {
+		if len(ssData[i]) == 0 {
+			partIDs[i] = -1
+			continue
+		}
+		partIDs[i] = int(ssData[i][len(ssData[i])-1])
+		if len(ssData[i]) != lenShare || len(ssData[i]) <= h.shardOffset() ||
+			!bytes.Equal(ssData[i][:len(raw)], raw) || partIDs[i] >= h.parts {
+			continue
+		}
+		ownFingerprints[i] = h.fingerprint(ssData[i])
+	}
+
+	// vote for the fingerprints, only the self-consistent shares can vote
+	votes := map[string]int{}
+	bestVotes := 0
+	var best []byte
+	for i := 0; i < len(ssData); i++ {
+		if ownFingerprints[i] == nil {
+			continue
+		}
+		fingerprints := h.fingerprintSection(ssData[i])
+		if !bytes.Equal(ownFingerprints[i], fingerprintOf(fingerprints, partIDs[i])) {
+			continue
+		}
+		votes[string(fingerprints)]++
+		if votes[string(fingerprints)] > bestVotes {
+			bestVotes = votes[string(fingerprints)]
+			best = fingerprints
+		}
+	}
+	for vector, count := range votes {
+		if count == bestVotes && vector != string(best) {
+			return nil, nil, fmt.Errorf("the shares are ambiguous, %d shares agree on different fingerprints", count)
+		}
+	}
+
+	// keep the shares matching the agreed fingerprints
+	var badPartIDs []int
+	goodShares := make([][]byte, 0, len(ssData))
+	isTaken := map[int]bool{}
+	for i := 0; i < len(ssData); i++ {
+		if best == nil || ownFingerprints[i] == nil ||
+			!bytes.Equal(ownFingerprints[i], fingerprintOf(best, partIDs[i])) {
+			badPartIDs = append(badPartIDs, partIDs[i])
+			continue
+		}
+		if isTaken[partIDs[i]] {
+			continue
+		}
+		isTaken[partIDs[i]] = true
+		goodShares = append(goodShares, ssData[i])
+	}
+	if len(goodShares) < h.threshold {
+		return nil, badPartIDs, fmt.Errorf(
+			"only %d consistent shares, at least %d are required, corrupted part-ids: %v",
+			len(goodShares), h.threshold, badPartIDs)
+	}
+
+	return goodShares, badPartIDs, nil
+}
+
+func fingerprintOf(fingerprints []byte, partID int) []byte {
+	return fingerprints[partID*LenFingerprint : (partID+1)*LenFingerprint]
+}
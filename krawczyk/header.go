@@ -0,0 +1,137 @@
+package krawczyk
+
+import (
+	"bytes"
+	"errors"
+	"github.com/fadhilkurnia/shamir/shamir"
+)
+
+// LenHeader is the size of the header at the beginning of every share:
+// 1 byte headerMagic, 1 byte cipher suite and flags, 1 byte #parts, and
+// 1 byte #threshold.
+const LenHeader = 4
+
+// headerMagic is the first byte of the shares with a header. The shares
+// of the original layout have no header, {metadata share, reed-solomon
+// shard, part-id}, and are always AES-128-OFB without fingerprints.
+const headerMagic = 0xa5
+
+// errNoHeader is returned by parseHeader when the shares have no header,
+// so they can only be combined knowing #parts and #threshold.
+var errNoHeader = errors.New("the shares have no header, #parts and #threshold are required to combine them")
+
+// flagFingerprints marks the shares carrying fingerprints,
+// stored in the highest bit of the suite byte.
+const flagFingerprints = 0x80
+
+// header describes how a share is laid out:
+// {header, metadata share, fingerprints (optional), reed-solomon shard, part-id}
+// where the header is missing in the original layout
+type header struct {
+	// legacy is set for the shares of the original layout, without header
+	legacy       bool
+	suite        Suite
+	fingerprints bool
+	parts        int
+	threshold    int
+}
+
+func (h header) bytes() []byte {
+	if h.legacy {
+		return nil
+	}
+	b := []byte{headerMagic, byte(h.suite), byte(h.parts), byte(h.threshold)}
+	if h.fingerprints {
+		b[1] |= flagFingerprints
+	}
+	return b
+}
+
+// len returns the size of the header, zero for the original layout.
+func (h header) len() int {
+	if h.legacy {
+		return 0
+	}
+	return LenHeader
+}
+
+// decodeHeader decodes the header at the beginning of a share,
+// ok is false when the share does not start with a valid header.
+func decodeHeader(share []byte) (h header, ok bool) {
+	if len(share) < LenHeader || share[0] != headerMagic {
+		return header{}, false
+	}
+	h = header{
+		suite:        Suite(share[1] &^ flagFingerprints),
+		fingerprints: share[1]&flagFingerprints != 0,
+		parts:        int(share[2]),
+		threshold:    int(share[3]),
+	}
+	if h.suite >= numSuites || h.threshold == 0 || h.threshold > h.parts {
+		return header{}, false
+	}
+	return h, true
+}
+
+// parseHeader reads the header of the given shares. When the shares have
+// different headers, the most common one is used, but it must be carried
+// by more than half of the shares. Otherwise errNoHeader is returned, since
+// the first bytes of the shares of the original layout are random.
+func parseHeader(ssData [][]byte) (header, error) {
+	var raw []byte
+	numShares := 0
+	counts := map[string]int{}
+	for _, share := range ssData {
+		if share == nil {
+			continue
+		}
+		numShares++
+		if _, ok := decodeHeader(share); !ok {
+			continue
+		}
+		counts[string(share[:LenHeader])]++
+		if raw == nil || counts[string(share[:LenHeader])] > counts[string(raw)] {
+			raw = share[:LenHeader]
+		}
+	}
+	if raw == nil || 2*counts[string(raw)] <= numShares {
+		return header{}, errNoHeader
+	}
+	h, _ := decodeHeader(raw)
+	return h, nil
+}
+
+// lenMetadata is the size of the shamir-shared metadata in each share:
+// the key, 4 bytes length, and 1 byte ss metadata.
+func (h header) lenMetadata() int {
+	return h.suite.KeySize() + LenLen + shamir.ShareOverhead
+}
+
+// shardOffset returns the position of the reed-solomon shard in a share,
+// which comes after the header, the metadata share and the optional fingerprints.
+func (h header) shardOffset() int {
+	offset := h.len() + h.lenMetadata()
+	if h.fingerprints {
+		offset += h.parts * LenFingerprint
+	}
+	return offset
+}
+
+// checkSame ensures all the shares have the same header and length.
+func (h header) checkSame(ssData [][]byte) error {
+	raw := h.bytes()
+	for i := 0; i < len(ssData); i++ {
+		if len(ssData[i]) != len(ssData[0]) {
+			return errors.New("all the secret-shared data must be the same length")
+		}
+		if len(ssData[i]) < len(raw) || !bytes.Equal(ssData[i][:len(raw)], raw) {
+			return errors.New("all the secret-shared data must have the same header")
+		}
+	}
+	return nil
+}
+
+// metadata returns the shamir-shared metadata of a share.
+func (h header) metadata(share []byte) []byte {
+	return share[h.len() : h.len()+h.lenMetadata()]
+}
@@ -2,8 +2,11 @@ package krawczyk
 
 import (
 	"bytes"
+	"context"
+	"encoding/hex"
 	"fmt"
 	"github.com/fadhilkurnia/shamir/csprng"
+	"github.com/fadhilkurnia/shamir/utils"
 	"github.com/klauspost/reedsolomon"
 	"math/rand"
 	"reflect"
@@ -98,7 +101,7 @@ func TestSplitCombine(t *testing.T) {
 	if !isEqual {
 		t.Errorf("The combined secret is different. Expected: '%v', but got '%v'.\n", string(secretMsg), string(combinedShares))
 	}
-	expectedLen := len(secretMsg)/(threshold) + 1 + 16 + 4 + 1 // 1 byte partID, 16 bytes key, 4 bytes length, 1 bytes ss metadata
+	expectedLen := len(secretMsg)/(threshold) + LenHeader + 1 + 16 + 4 + 1 // header, 1 byte partID, 16 bytes key, 4 bytes length, 1 bytes ss metadata
 	if len(secretMsg) % (threshold) != 0 {
 		expectedLen += 1
 	}
@@ -402,4 +405,363 @@ func TestParallelSplit(t *testing.T) {
 	dur := time.Since(start)
 	t.Log("duration ", dur)
 	t.Log("capacity ", float64(numRequest)/dur.Seconds(), "req/s", numThreads, "threads")
-}
\ No newline at end of file
+}
+
+func TestSplitterSplitCombine(t *testing.T) {
+	secretMsg := []byte("The quick brown fox jumps over the lazy dog.")
+	parts := 5
+	threshold := 3
+
+	s, err := NewSplitter(parts, threshold)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := csprng.NewCSPRNG()
+	shares, err := s.SplitWithRandomizer(secretMsg, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the splitter output must be compatible with the package-level Combine
+	combinedShares, err := Combine(shares[2:], parts, threshold)
+	if err != nil {
+		t.Errorf("failed to combine the message: %v", err)
+	}
+	if !reflect.DeepEqual(secretMsg, combinedShares) {
+		t.Errorf("The combined secret is different. Expected: '%v', but got '%v'.\n", string(secretMsg), string(combinedShares))
+	}
+
+	shares[0] = nil
+	shares[3] = nil
+	combinedShares, err = s.Combine(shares)
+	if err != nil {
+		t.Errorf("failed to combine the message: %v", err)
+	}
+	if !reflect.DeepEqual(secretMsg, combinedShares) {
+		t.Errorf("The combined secret is different. Expected: '%v', but got '%v'.\n", string(secretMsg), string(combinedShares))
+	}
+}
+
+func TestSplitterConcurrent(t *testing.T) {
+	s, err := NewSplitter(4, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := csprng.NewCSPRNG()
+			for j := 1; j < 2_000; j += 7 {
+				secretMsg := make([]byte, j)
+				_, _ = r.Read(secretMsg)
+				shares, err := s.SplitWithRandomizer(secretMsg, r)
+				if err != nil {
+					t.Errorf("failed to split the message: %v", err)
+					return
+				}
+				combinedShares, err := s.Combine(shares[1:3])
+				if err != nil {
+					t.Errorf("failed to combine the message: %v", err)
+					return
+				}
+				if !bytes.Equal(secretMsg, combinedShares) {
+					t.Errorf("The combined secret is different for %d bytes secret", j)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestRepair(t *testing.T) {
+	secretMsg := []byte("The quick brown fox jumps over the lazy dog.")
+	parts := 5
+	threshold := 3
+
+	shares, err := Split(secretMsg, parts, threshold)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for lost := 0; lost < parts; lost++ {
+		available := make([][]byte, 0, parts-1)
+		for i := 0; i < parts; i++ {
+			if i != lost {
+				available = append(available, shares[i])
+			}
+		}
+		repaired, err := Repair(available[:threshold], lost, parts, threshold)
+		if err != nil {
+			t.Fatalf("failed to repair part %d: %v", lost, err)
+		}
+		if !bytes.Equal(repaired, shares[lost]) {
+			t.Errorf("the repaired share of part %d is different, expected %v, but got %v", lost, shares[lost], repaired)
+		}
+
+		combinedShares, err := Combine([][]byte{repaired, available[0], available[1]}, parts, threshold)
+		if err != nil {
+			t.Errorf("failed to combine the message: %v", err)
+		}
+		if !reflect.DeepEqual(secretMsg, combinedShares) {
+			t.Errorf("The combined secret is different. Expected: '%v', but got '%v'.\n", string(secretMsg), string(combinedShares))
+		}
+	}
+
+	if _, err := Repair(shares[:threshold], 0, parts, threshold); err == nil {
+		t.Errorf("expecting an error when repairing an available part")
+	}
+	if _, err := Repair(shares[1:threshold], 0, parts, threshold); err == nil {
+		t.Errorf("expecting an error when repairing with less than threshold shares")
+	}
+}
+
+func TestSplitCombineVerifiable(t *testing.T) {
+	secretMsg := []byte("The quick brown fox jumps over the lazy dog.")
+	parts := 5
+	threshold := 2
+
+	shares, err := SplitVerifiable(secretMsg, parts, threshold)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedLen := len(secretMsg)/threshold + LenHeader + 1 + 16 + 4 + 1 + parts*LenFingerprint
+	if len(shares[0]) != expectedLen {
+		t.Errorf("the expected length of a single share is %d, but got %d", expectedLen, len(shares[0]))
+	}
+
+	// corrupt the reed-solomon shard of part 1 and the metadata of part 3
+	shares[1][len(shares[1])-2] ^= 0xff
+	shares[3][LenHeader] ^= 0xff
+
+	combinedShares, badPartIDs, err := CombineVerifiable(shares, parts, threshold)
+	if err != nil {
+		t.Fatalf("failed to combine the message: %v", err)
+	}
+	if !reflect.DeepEqual(secretMsg, combinedShares) {
+		t.Errorf("The combined secret is different. Expected: '%v', but got '%v'.\n", string(secretMsg), string(combinedShares))
+	}
+	if !reflect.DeepEqual(badPartIDs, []int{1, 3}) {
+		t.Errorf("expecting part 1 and 3 to be detected as corrupted, but got %v", badPartIDs)
+	}
+
+	// a corrupted share can not be used with less than threshold good shares
+	if _, _, err := CombineVerifiable(shares[:2], parts, threshold); err == nil {
+		t.Errorf("expecting an error when combining with a corrupted share")
+	}
+
+	// the corrupted share can be repaired from the good ones
+	s, _ := NewSplitter(parts, threshold, WithFingerprints())
+	repaired, err := s.Repair([][]byte{shares[0], shares[2]}, 1)
+	if err != nil {
+		t.Fatalf("failed to repair part 1: %v", err)
+	}
+	shares[1] = repaired
+	if _, badPartIDs, _ = s.CombineVerifiable(shares); !reflect.DeepEqual(badPartIDs, []int{3}) {
+		t.Errorf("expecting only part 3 to be detected as corrupted, but got %v", badPartIDs)
+	}
+}
+
+func TestSplitCombineSuites(t *testing.T) {
+	secretMsg := []byte("The quick brown fox jumps over the lazy dog.")
+	parts := 5
+	threshold := 3
+
+	for _, suite := range []Suite{SuiteAES128OFB, SuiteAES128GCM, SuiteAES256GCM, SuiteChaCha20Poly1305} {
+		s, err := NewSplitter(parts, threshold, WithSuite(suite))
+		if err != nil {
+			t.Fatal(err)
+		}
+		shares, err := s.Split(secretMsg)
+		if err != nil {
+			t.Fatalf("failed to split the message with %v: %v", suite, err)
+		}
+		lenCiphertext := len(secretMsg) + suite.Overhead()
+		expectedLen := (lenCiphertext+threshold-1)/threshold + LenHeader + suite.KeySize() + 4 + 1 + 1
+		if len(shares[0]) != expectedLen {
+			t.Errorf("the expected length of a single share with %v is %d, but got %d", suite, expectedLen, len(shares[0]))
+		}
+
+		// the suite is detected from the shares
+		combinedShares, err := Combine(shares[parts-threshold:], parts, threshold)
+		if err != nil {
+			t.Fatalf("failed to combine the message with %v: %v", suite, err)
+		}
+		if !reflect.DeepEqual(secretMsg, combinedShares) {
+			t.Errorf("The combined secret is different. Expected: '%v', but got '%v'.\n", string(secretMsg), string(combinedShares))
+		}
+
+		repaired, err := s.Repair(shares[1:threshold+1], 0)
+		if err != nil {
+			t.Fatalf("failed to repair part 0 with %v: %v", suite, err)
+		}
+		if !bytes.Equal(repaired, shares[0]) {
+			t.Errorf("the repaired share with %v is different, expected %v, but got %v", suite, shares[0], repaired)
+		}
+	}
+}
+
+func TestCombineAuthenticated(t *testing.T) {
+	secretMsg := []byte("The quick brown fox jumps over the lazy dog.")
+	parts := 5
+	threshold := 2
+
+	s, _ := NewSplitter(parts, threshold, WithSuite(SuiteAES256GCM))
+	shares, err := s.Split(secretMsg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// tampering a data shard is detected by the authenticated suites
+	shares[0][len(shares[0])-2] ^= 0xff
+	if _, err := Combine(shares[:threshold], parts, threshold); err == nil {
+		t.Errorf("expecting an error when combining a tampered share")
+	}
+
+	// tampering the suite in the header is detected as well
+	shares[0][len(shares[0])-2] ^= 0xff
+	shares[0][0] = byte(SuiteAES128OFB)
+	shares[1][0] = byte(SuiteAES128OFB)
+	if _, err := Combine(shares[:threshold], parts, threshold); err == nil {
+		t.Errorf("expecting an error when combining shares with a tampered header")
+	}
+}
+
+func TestCombineContext(t *testing.T) {
+	parts := 5
+	threshold := 3
+	secretMsg := make([]byte, 2*threshold*stripeSize+123)
+	rand.Read(secretMsg)
+
+	for _, suite := range []Suite{SuiteAES128OFB, SuiteAES128GCM} {
+		s, _ := NewSplitter(parts, threshold, WithSuite(suite))
+		shares, err := s.Split(secretMsg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the data shards are missing, thus reconstructed stripe by stripe
+		combinedShares, err := s.CombineContext(context.Background(), shares[parts-threshold:])
+		if err != nil {
+			t.Fatalf("failed to combine the message with %v: %v", suite, err)
+		}
+		if !bytes.Equal(secretMsg, combinedShares) {
+			t.Errorf("The combined secret with %v is different.\n", suite)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := CombineContext(ctx, shares[parts-threshold:], parts, threshold); err != context.Canceled {
+			t.Errorf("expecting context.Canceled with %v, but got %v", suite, err)
+		}
+	}
+}
+
+func TestCombineSecret(t *testing.T) {
+	secretMsg := []byte("The quick brown fox jumps over the lazy dog.")
+	parts := 5
+	threshold := 3
+
+	// the key, the metadata and the secret are in locked memory
+	a := utils.NewLockedAllocator()
+	defer a.Close()
+	utils.SetAllocator(a)
+	defer utils.SetAllocator(nil)
+
+	for _, suite := range []Suite{SuiteAES128OFB, SuiteChaCha20Poly1305} {
+		s, _ := NewSplitter(parts, threshold, WithSuite(suite))
+		shares, err := s.Split(secretMsg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		secret, err := s.CombineSecret(shares[parts-threshold:])
+		if err != nil {
+			t.Fatalf("failed to combine the message with %v: %v", suite, err)
+		}
+		if !bytes.Equal(secretMsg, secret.Bytes()) {
+			t.Errorf("The combined secret is different. Expected: '%v', but got '%v'.\n", string(secretMsg), string(secret.Bytes()))
+		}
+		if a.Fallbacks() == 0 && !secret.Locked() {
+			t.Errorf("the combined secret with %v is not in locked memory", suite)
+		}
+		secret.Destroy()
+	}
+}
+
+// legacyShares are generated by Split before the shares carried a header,
+// splitting "The quick brown fox jumps over the lazy dog" into 5 parts
+// with threshold 3.
+var legacyShares = []string{
+	"6dea9ca6ea8739053fa05c92b20dd1ec9176692ffa829284577bc16e79b6df4414e91cc900",
+	"919fefe961bfbfa6a2bf6f86f91fd1f763a656c89b8da042f700cf92b40a4a3668c41a4c01",
+	"818d2d3454171d0f477de5b77510b12dd54893b741684887d10d844c3cf9754595c6000002",
+	"c36e2101b42c5af8fc999d75fe6479d6bf86bf5f53677a4171768ab0f145e037e9eb068503",
+	"ee4377fe2054f8e4dc4410187c7fa60f3169e9ef49bcf9f00dad3211dc80d8f5f27e641904",
+}
+
+func TestCombineLegacy(t *testing.T) {
+	secretMsg := []byte("The quick brown fox jumps over the lazy dog")
+	shares := make([][]byte, len(legacyShares))
+	for i, s := range legacyShares {
+		shares[i], _ = hex.DecodeString(s)
+	}
+
+	for _, present := range [][]int{{0, 1, 2}, {2, 3, 4}, {0, 2, 4}, {0, 1, 2, 3, 4}} {
+		ssData := make([][]byte, len(shares))
+		for _, i := range present {
+			ssData[i] = shares[i]
+		}
+		combinedShares, err := Combine(ssData, 5, 3)
+		if err != nil {
+			t.Fatalf("failed to combine the legacy shares %v: %v", present, err)
+		}
+		if !reflect.DeepEqual(secretMsg, combinedShares) {
+			t.Errorf("The combined secret is different. Expected: '%v', but got '%v'.\n", string(secretMsg), string(combinedShares))
+		}
+	}
+
+	// the legacy shares do not record #parts and #threshold
+	if _, err := NewScheme().Combine(shares); err == nil {
+		t.Errorf("expecting an error when combining legacy shares without #parts and #threshold")
+	}
+}
+
+func TestRepairLegacy(t *testing.T) {
+	secretMsg := []byte("The quick brown fox jumps over the lazy dog")
+	shares := make([][]byte, len(legacyShares))
+	for i, s := range legacyShares {
+		shares[i], _ = hex.DecodeString(s)
+	}
+	lenMetadata := LenKey + LenLen + 1
+
+	for lost := range shares {
+		var available [][]byte
+		for i := range shares {
+			if i != lost {
+				available = append(available, shares[i])
+			}
+		}
+		repaired, err := Repair(available, lost, 5, 3)
+		if err != nil {
+			t.Fatalf("failed to repair part %d: %v", lost, err)
+		}
+		if !bytes.Equal(repaired[lenMetadata:], shares[lost][lenMetadata:]) {
+			t.Errorf("the repaired shard of part %d is different, expected %v, but got %v", lost, shares[lost], repaired)
+		}
+
+		combinedShares, err := Combine([][]byte{repaired, available[0], available[1]}, 5, 3)
+		if err != nil {
+			t.Fatalf("failed to combine the message: %v", err)
+		}
+		if !reflect.DeepEqual(secretMsg, combinedShares) {
+			t.Errorf("The combined secret is different. Expected: '%v', but got '%v'.\n", string(secretMsg), string(combinedShares))
+		}
+
+		if _, err = Repair(available[:3], lost, 5, 3); err == nil {
+			t.Errorf("repairing part %d from only 3 of the other legacy shares should fail", lost)
+		}
+	}
+}
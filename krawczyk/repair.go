@@ -0,0 +1,133 @@
+package krawczyk
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/fadhilkurnia/shamir/shamir"
+)
+
+// Repair regenerates the share of the lost part from at least `threshold`
+// available shares, without decrypting or reassembling the secret. The lost
+// reed-solomon shard is reconstructed from the other shards, and the lost
+// metadata share is interpolated at its x coordinate. The other shares
+// remain valid and the repaired share is identical to the lost one.
+//
+// The shares of older versions, including the shares without header, have
+// their metadata shares at random x coordinates, so the x coordinate of
+// the lost one is unknown. Their lost metadata share is regenerated at a
+// new x coordinate instead, unused by the available shares: the repaired
+// share differs from the lost one, but combines with the other shares.
+// All the remaining shares are required then, so the new x coordinate does
+// not collide with any of them.
+//
+// Note that the repair process still receives enough shares to recover
+// the secret, so it must be trusted not to combine them.
+func Repair(available [][]byte, lostPartID, parts, threshold int) ([]byte, error) {
+	s, err := NewSplitter(parts, threshold)
+	if err != nil {
+		return nil, err
+	}
+	return s.Repair(available, lostPartID)
+}
+
+// Repair regenerates the share of the lost part, see the package-level Repair.
+func (s *Splitter) Repair(available [][]byte, lostPartID int) ([]byte, error) {
+	if lostPartID < 0 || lostPartID >= s.hdr.parts {
+		return nil, fmt.Errorf("the lost part-id should be less than the number of parts, part-id=%d", lostPartID)
+	}
+
+	// remove empty shares
+	ssData := make([][]byte, 0, len(available))
+	for i := 0; i < len(available); i++ {
+		if available[i] != nil {
+			ssData = append(ssData, available[i])
+		}
+	}
+	if len(ssData) < s.hdr.threshold {
+		return nil, fmt.Errorf("at least %d shares are required to repair a share, got %d", s.hdr.threshold, len(ssData))
+	}
+	h, err := s.parseHeader(ssData)
+	if err != nil {
+		return nil, err
+	}
+
+	// only use the shares consistent with the fingerprints
+	if h.fingerprints {
+		if ssData, _, err = h.verify(ssData); err != nil {
+			return nil, err
+		}
+	} else if err = h.checkSame(ssData); err != nil {
+		return nil, err
+	}
+	offset := h.shardOffset()
+	shardSize := len(ssData[0]) - offset - 1
+	if shardSize <= 0 {
+		return nil, errors.New("the given secret-shared data is too short")
+	}
+
+	// split encoded data and secret-shared metadata
+	lenMetadata := h.lenMetadata()
+	pinned := !h.legacy
+	encodedData := make([][]byte, h.parts)
+	ssMetadata := make([][]byte, len(ssData))
+	for i := 0; i < len(ssData); i++ {
+		partID := int(ssData[i][len(ssData[i])-1])
+		if partID >= h.parts {
+			return nil, errors.New("the given secret-shared data is wrong, part-id should be less than the number of parts")
+		}
+		if partID == lostPartID {
+			return nil, fmt.Errorf("the share of part %d is not lost", lostPartID)
+		}
+		if encodedData[partID] != nil {
+			return nil, fmt.Errorf("duplicate share of part %d", partID)
+		}
+		ssMetadata[i] = h.metadata(ssData[i])
+		if ssMetadata[i][lenMetadata-1] != s.xCoordinates[partID] {
+			pinned = false
+		}
+		encodedData[partID] = ssData[i][offset : len(ssData[i])-1]
+	}
+
+	// rebuild the lost reed-solomon shard, only the data shards
+	// are needed when the lost part is a data shard
+	if lostPartID < h.threshold {
+		err = s.encoder.ReconstructData(encodedData)
+	} else {
+		err = s.encoder.Reconstruct(encodedData)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconstruct the lost shard: %v", err)
+	}
+
+	// regenerate the lost metadata share, at its x coordinate if known
+	var newMetadata [][]byte
+	if !pinned && len(ssData) != h.parts-1 {
+		return nil, fmt.Errorf("the x coordinate of the lost metadata share is unknown, all the other %d shares are required to repair it, got %d", h.parts-1, len(ssData))
+	}
+	if pinned {
+		newMetadata, err = shamir.RegenerateAt(ssMetadata, s.xCoordinates[lostPartID:lostPartID+1])
+	} else {
+		newMetadata, err = shamir.Regenerate(ssMetadata, 1)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to regenerate the metadata share: %v", err)
+	}
+
+	share := make([]byte, offset+shardSize+1)
+	copy(share, h.bytes())
+	copy(h.metadata(share), newMetadata[0])
+	copy(share[offset:], encodedData[lostPartID])
+	share[offset+shardSize] = byte(lostPartID)
+
+	// the repaired share must match the fingerprint of the lost share
+	if h.fingerprints {
+		fingerprints := h.fingerprintSection(ssData[0])
+		copy(h.fingerprintSection(share), fingerprints)
+		if !bytes.Equal(h.fingerprint(share), fingerprintOf(fingerprints, lostPartID)) {
+			return nil, errors.New("the repaired share does not match its fingerprint")
+		}
+	}
+
+	return share, nil
+}
@@ -0,0 +1,79 @@
+package krawczyk
+
+import (
+	"context"
+	"github.com/fadhilkurnia/shamir/csprng"
+)
+
+// Scheme is SSMS as a secret-sharing scheme, configured with the
+// Splitter options. The number of parts, the threshold, and the
+// options used in Split are read from the shares when combining.
+type Scheme struct {
+	opts []Option
+}
+
+// NewScheme creates a Scheme that splits with the given options.
+func NewScheme(opts ...Option) Scheme {
+	return Scheme{opts: opts}
+}
+
+// Name returns the name of the scheme.
+func (Scheme) Name() string {
+	return "krawczyk"
+}
+
+// Split is the same as SplitWithRandomizer, with the Scheme's options.
+func (c Scheme) Split(secret []byte, parts, threshold int, randomizer *csprng.CSPRNG) ([][]byte, error) {
+	s, err := NewSplitter(parts, threshold, c.opts...)
+	if err != nil {
+		return nil, err
+	}
+	return s.SplitWithRandomizer(secret, randomizer)
+}
+
+// Combine reconstructs the secret from the shares, see Splitter.Combine.
+func (Scheme) Combine(shares [][]byte) ([]byte, error) {
+	h, err := parseHeader(shares)
+	if err != nil {
+		return nil, err
+	}
+	s, err := NewSplitter(h.parts, h.threshold)
+	if err != nil {
+		return nil, err
+	}
+	return s.Combine(shares)
+}
+
+// SplitContext is the same as Split, but returns ctx.Err()
+// without splitting when ctx is already done.
+func (c Scheme) SplitContext(ctx context.Context, secret []byte, parts, threshold int, randomizer *csprng.CSPRNG) ([][]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.Split(secret, parts, threshold, randomizer)
+}
+
+// CombineContext is the same as Combine, but ctx is checked between
+// stripes, see Splitter.CombineContext.
+func (Scheme) CombineContext(ctx context.Context, shares [][]byte) ([]byte, error) {
+	h, err := parseHeader(shares)
+	if err != nil {
+		return nil, err
+	}
+	s, err := NewSplitter(h.parts, h.threshold)
+	if err != nil {
+		return nil, err
+	}
+	return s.CombineContext(ctx, shares)
+}
+
+// ShareOverhead returns how many bytes each share is larger than
+// len(secret)/threshold, the smallest possible share size.
+func (c Scheme) ShareOverhead(secretLen, parts, threshold int) int {
+	s := Splitter{hdr: header{parts: parts, threshold: threshold}}
+	for _, opt := range c.opts {
+		opt(&s)
+	}
+	lenCiphertext := secretLen + s.hdr.suite.Overhead()
+	return (lenCiphertext+threshold-1)/threshold + s.hdr.shardOffset() + 1 - (secretLen+threshold-1)/threshold
+}
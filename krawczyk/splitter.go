@@ -0,0 +1,476 @@
+package krawczyk
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/fadhilkurnia/shamir/csprng"
+	"github.com/fadhilkurnia/shamir/shamir"
+	"github.com/fadhilkurnia/shamir/utils"
+	"github.com/klauspost/reedsolomon"
+	"math"
+	"sync"
+)
+
+// stripeSize is the number of bytes of each shard decoded and decrypted
+// between two checks of the context in CombineContext.
+const stripeSize = 256 << 10
+
+type encoderKey struct {
+	dataShards   int
+	parityShards int
+}
+
+// encoderCache keeps a single reed-solomon encoder for each (data, parity)
+// configuration. Building the encoding matrix is the most expensive part
+// of splitting small secrets, and the encoders are safe for concurrent use.
+var encoderCache sync.Map
+
+func getEncoder(parts, threshold int) (reedsolomon.Encoder, error) {
+	key := encoderKey{threshold, parts - threshold}
+	if enc, ok := encoderCache.Load(key); ok {
+		return enc.(reedsolomon.Encoder), nil
+	}
+	enc, err := reedsolomon.New(threshold, parts-threshold)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := encoderCache.LoadOrStore(key, enc)
+	return actual.(reedsolomon.Encoder), nil
+}
+
+// Splitter does SSMS secret-sharing for a fixed number of parts and
+// threshold. The reed-solomon encoder is built once and the ciphertext is
+// written directly into the output shares, without intermediate buffers.
+// A Splitter is safe for concurrent use, as long as each goroutine uses
+// its own randomizer.
+type Splitter struct {
+	hdr     header
+	encoder reedsolomon.Encoder
+
+	// metadata share of part i is always at x=i+1, thus
+	// the lost metadata share can be regenerated in Repair
+	xCoordinates []byte
+}
+
+// Option configures a Splitter.
+type Option func(*Splitter)
+
+// WithFingerprints makes every share carry the fingerprints of all the
+// shares, so Combine can detect and drop corrupted shares.
+func WithFingerprints() Option {
+	return func(s *Splitter) {
+		s.hdr.fingerprints = true
+	}
+}
+
+// WithSuite sets the cipher suite used to encrypt the secret,
+// the default is SuiteAES128OFB.
+func WithSuite(suite Suite) Option {
+	return func(s *Splitter) {
+		s.hdr.suite = suite
+	}
+}
+
+// NewSplitter creates a Splitter that generates `parts` shares, `threshold`
+// of which are required to reconstruct the secret.
+func NewSplitter(parts, threshold int, opts ...Option) (*Splitter, error) {
+	if threshold == 0 || parts == 0 {
+		return nil, errors.New("#parts and #threshold can not be zero")
+	}
+	if threshold > parts {
+		return nil, fmt.Errorf(
+			"threshold should be less to the number of parts, #parts=%d $threshold=%d", parts, threshold)
+	}
+	if threshold > 255 || parts > 255 {
+		return nil, fmt.Errorf(
+			"#parts and #threshold should be less than 256, #parts=%d $threshold=%d", parts, threshold)
+	}
+
+	encoder, err := getEncoder(parts, threshold)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize reed-solomon encoder: %v", err)
+	}
+
+	xCoordinates := make([]byte, parts)
+	for i := range xCoordinates {
+		xCoordinates[i] = byte(i + 1)
+	}
+
+	s := &Splitter{
+		hdr:          header{suite: SuiteAES128OFB, parts: parts, threshold: threshold},
+		encoder:      encoder,
+		xCoordinates: xCoordinates,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.hdr.suite >= numSuites {
+		return nil, fmt.Errorf("unknown cipher suite %v", s.hdr.suite)
+	}
+	return s, nil
+}
+
+// Split secret-shares the secret, the key is generated with crypto/rand.
+func (s *Splitter) Split(secret []byte) ([][]byte, error) {
+	return s.split(secret, nil)
+}
+
+// SplitWithRandomizer is exactly the same with Split but with randomizer
+// provided by the caller.
+func (s *Splitter) SplitWithRandomizer(secret []byte, randomizer *csprng.CSPRNG) ([][]byte, error) {
+	return s.split(secret, randomizer)
+}
+
+func (s *Splitter) split(secret []byte, randomizer *csprng.CSPRNG) ([][]byte, error) {
+	if len(secret) > math.MaxUint32-s.hdr.suite.Overhead() {
+		return nil, fmt.Errorf(
+			"the provided secret is to large, we can only split up to %d bytes data",
+			math.MaxUint32-s.hdr.suite.Overhead())
+	}
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("failed to encode the secret: %v", reedsolomon.ErrShortData)
+	}
+
+	// generate random key, followed by the secret length, those
+	// will be secret-shared with shamir's secret-sharing
+	keySize := s.hdr.suite.KeySize()
+	keyLenPairBuff := utils.NewSecretBuffer(keySize + LenLen)
+	defer keyLenPairBuff.Destroy()
+	keyLenPair := keyLenPairBuff.Bytes()
+	key := keyLenPair[:keySize]
+	var err error
+	if randomizer != nil {
+		_, err = randomizer.Read(key)
+	} else {
+		_, err = rand.Read(key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate secret key: %v", err)
+	}
+	binary.LittleEndian.PutUint32(keyLenPair[keySize:], uint32(len(secret)))
+
+	// each share is {header, metadata share, fingerprints (optional), reed-solomon shard, part-id}
+	rawHeader := s.hdr.bytes()
+	offset := s.hdr.shardOffset()
+	lenCiphertext := len(secret) + s.hdr.suite.Overhead()
+	shardSize := (lenCiphertext + s.hdr.threshold - 1) / s.hdr.threshold
+	results := newByteMatrix(s.hdr.parts, offset+shardSize+1)
+	shards := make([][]byte, s.hdr.parts)
+	for i := 0; i < s.hdr.parts; i++ {
+		copy(results[i], rawHeader)
+		shards[i] = results[i][offset : offset+shardSize]
+		results[i][offset+shardSize] = byte(i)
+	}
+
+	// encrypt the secret into the data shards, the last
+	// data shard is padded with zeros as in reedsolomon.Split
+	if err := s.encrypt(shards, secret, key, rawHeader); err != nil {
+		return nil, err
+	}
+
+	// generate the parity shards
+	if err := s.encoder.Encode(shards); err != nil {
+		return nil, fmt.Errorf("failed to encode the secret: %v", err)
+	}
+
+	// secret-share the key & len with shamir's secret-sharing
+	ssKeyLenPair, err := shamir.SplitAt(keyLenPair, s.xCoordinates, s.hdr.threshold, randomizer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to secret-shares the key and len: %v", err)
+	}
+	for i := 0; i < s.hdr.parts; i++ {
+		copy(s.hdr.metadata(results[i]), ssKeyLenPair[i])
+	}
+
+	if s.hdr.fingerprints {
+		s.hdr.setFingerprints(results)
+	}
+
+	return results, nil
+}
+
+// encrypt writes the ciphertext of the secret into the data shards. The
+// unauthenticated suite encrypts directly into the shards, while the
+// authenticated suites seal the secret, bound to the header, then scatter it.
+func (s *Splitter) encrypt(shards [][]byte, secret, key, rawHeader []byte) error {
+	if s.hdr.suite == SuiteAES128OFB {
+		stream, err := newStream(key)
+		if err != nil {
+			return fmt.Errorf("failed to initialize aes: %v", err)
+		}
+		remaining := secret
+		for i := 0; i < s.hdr.threshold && len(remaining) > 0; i++ {
+			n := len(shards[i])
+			if n > len(remaining) {
+				n = len(remaining)
+			}
+			stream.XORKeyStream(shards[i][:n], remaining[:n])
+			remaining = remaining[n:]
+		}
+		return nil
+	}
+
+	aead, err := s.hdr.suite.newAEAD(key)
+	if err != nil {
+		return fmt.Errorf("failed to initialize %v: %v", s.hdr.suite, err)
+	}
+	nonce := make([]byte, aead.NonceSize())
+	ciphertext := aead.Seal(make([]byte, 0, len(secret)+aead.Overhead()), nonce, secret, rawHeader)
+	for i := 0; i < s.hdr.threshold && len(ciphertext) > 0; i++ {
+		n := copy(shards[i], ciphertext)
+		ciphertext = ciphertext[n:]
+	}
+	return nil
+}
+
+// Combine reconstructs the secret from at least `threshold` shares. Missing
+// shares can be omitted or given as nil. The cipher suite is read from the
+// shares. With fingerprints, the corrupted shares are dropped before
+// reconstructing the secret.
+func (s *Splitter) Combine(ssData [][]byte) ([]byte, error) {
+	secret, _, err := s.CombineVerifiable(ssData)
+	return secret, err
+}
+
+// CombineVerifiable is similar with Combine, but it also returns the
+// part-ids of the shares that are inconsistent with the fingerprints,
+// those shares are not used to reconstruct the secret. Without
+// fingerprints, no share is ever reported as corrupted.
+func (s *Splitter) CombineVerifiable(ssData [][]byte) ([]byte, []int, error) {
+	return s.combineVerifiable(context.Background(), ssData, makeBuffer)
+}
+
+// CombineContext is similar with Combine, but the reed-solomon decoding and
+// the decryption are done stripe by stripe, and ctx is checked between
+// stripes. ctx.Err() is returned once ctx is done.
+func (s *Splitter) CombineContext(ctx context.Context, ssData [][]byte) ([]byte, error) {
+	secret, _, err := s.combineVerifiable(ctx, ssData, makeBuffer)
+	return secret, err
+}
+
+// CombineSecret is similar with Combine, but the secret is decrypted into
+// a SecretBuffer, which the caller wipes with Destroy once done. The
+// SecretBuffer comes from the allocator set with utils.SetAllocator.
+func (s *Splitter) CombineSecret(ssData [][]byte) (*utils.SecretBuffer, error) {
+	var secret *utils.SecretBuffer
+	plaintext, _, err := s.combineVerifiable(context.Background(), ssData, func(size int) []byte {
+		secret = utils.NewSecretBuffer(size)
+		return secret.Bytes()
+	})
+	if err != nil {
+		if secret != nil {
+			secret.Destroy()
+		}
+		return nil, err
+	}
+	secret.Truncate(len(plaintext))
+	return secret, nil
+}
+
+// makeBuffer allocates the buffer the secret is decrypted into.
+func makeBuffer(size int) []byte {
+	return make([]byte, size)
+}
+
+func (s *Splitter) combineVerifiable(ctx context.Context, ssData [][]byte, newBuffer func(size int) []byte) ([]byte, []int, error) {
+	// remove empty shares
+	cleanSSData := make([][]byte, 0, len(ssData))
+	for i := 0; i < len(ssData); i++ {
+		if ssData[i] != nil {
+			cleanSSData = append(cleanSSData, ssData[i])
+		}
+	}
+	ssData = cleanSSData
+	if len(ssData) == 0 {
+		return nil, nil, errors.New("no secret-shared data is given")
+	}
+	if len(ssData[0]) > math.MaxUint32 {
+		return nil, nil, fmt.Errorf(
+			"the provided secret is too large, we can only combine up to %d bytes data", math.MaxUint32)
+	}
+
+	h, err := s.parseHeader(ssData)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var badPartIDs []int
+	if h.fingerprints {
+		ssData, badPartIDs, err = h.verify(ssData)
+		if err != nil {
+			return nil, badPartIDs, err
+		}
+	} else if err = h.checkSame(ssData); err != nil {
+		return nil, nil, err
+	}
+	if len(ssData[0]) <= h.shardOffset()+1 {
+		return nil, badPartIDs, errors.New("the given secret-shared data is too short")
+	}
+
+	secret, err := s.combine(ctx, h, ssData, newBuffer)
+	return secret, badPartIDs, err
+}
+
+// parseHeader reads the header of the shares, which must be generated
+// for the same number of parts and threshold as the Splitter. The shares
+// without header are read with the original layout.
+func (s *Splitter) parseHeader(ssData [][]byte) (header, error) {
+	h, err := parseHeader(ssData)
+	if err == errNoHeader {
+		return header{legacy: true, suite: SuiteAES128OFB, parts: s.hdr.parts, threshold: s.hdr.threshold}, nil
+	}
+	if err != nil {
+		return header{}, err
+	}
+	if h.parts != s.hdr.parts || h.threshold != s.hdr.threshold {
+		return header{}, fmt.Errorf(
+			"the shares are generated for #parts=%d #threshold=%d, but expecting #parts=%d #threshold=%d",
+			h.parts, h.threshold, s.hdr.parts, s.hdr.threshold)
+	}
+	return h, nil
+}
+
+func (s *Splitter) combine(ctx context.Context, h header, ssData [][]byte, newBuffer func(size int) []byte) ([]byte, error) {
+	// split encoded data and secret-shared metadata
+	offset := h.shardOffset()
+	shardSize := len(ssData[0]) - offset - 1
+	encodedData := make([][]byte, h.parts)
+	ssMetadata := make([][]byte, len(ssData))
+	for i := 0; i < len(ssData); i++ {
+		ssMetadata[i] = h.metadata(ssData[i])
+
+		// check the part-id of the reed-solomon encoded data
+		partID := ssData[i][len(ssData[i])-1]
+		if int(partID) >= h.parts {
+			return nil, errors.New("the given secret-shared data is wrong, part-id should be less than the number of parts")
+		}
+		encodedData[partID] = ssData[i][offset : len(ssData[i])-1]
+	}
+
+	// get the metadata
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	metadataBuff, err := shamir.CombineSecret(ssMetadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the metadata: %v", err)
+	}
+	defer metadataBuff.Destroy()
+	metadata := metadataBuff.Bytes()
+	keySize := h.suite.KeySize()
+	key := metadata[:keySize]
+	length := int(binary.LittleEndian.Uint32(metadata[keySize:]))
+	if length+h.suite.Overhead() > shardSize*h.threshold {
+		return nil, errors.New("the given secret-shared data is wrong, secret length exceeds the data shards")
+	}
+
+	// decode the ciphertext
+	if err = s.reconstructData(ctx, encodedData, shardSize); err != nil {
+		return nil, err
+	}
+
+	return h.decrypt(ctx, encodedData[:h.threshold], length, key, newBuffer(length+h.suite.Overhead()))
+}
+
+// reconstructData reconstructs the missing data shards stripe by stripe,
+// since reed-solomon works column by column, checking ctx between stripes.
+func (s *Splitter) reconstructData(ctx context.Context, shards [][]byte, shardSize int) error {
+	var missing []int
+	for i := 0; i < s.hdr.threshold; i++ {
+		if shards[i] == nil {
+			missing = append(missing, i)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	buff := make([]byte, len(missing)*shardSize)
+	for j, i := range missing {
+		shards[i] = buff[j*shardSize : (j+1)*shardSize]
+	}
+	stripe := make([][]byte, len(shards))
+	for from := 0; from < shardSize; from += stripeSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		to := from + stripeSize
+		if to > shardSize {
+			to = shardSize
+		}
+
+		// the missing shards are given with zero length, but with
+		// enough capacity for the reconstructed stripe
+		for i, shard := range shards {
+			stripe[i] = nil
+			if shard != nil {
+				stripe[i] = shard[from:to]
+			}
+		}
+		for _, i := range missing {
+			stripe[i] = shards[i][from:from]
+		}
+		if err := s.encoder.ReconstructData(stripe); err != nil {
+			return fmt.Errorf("failed to reconstruct data: %v", err)
+		}
+	}
+	return nil
+}
+
+// decrypt reads the ciphertext from the data shards and returns
+// the secret of the given length.
+func (h header) decrypt(ctx context.Context, shards [][]byte, length int, key []byte, buff []byte) ([]byte, error) {
+	if h.suite == SuiteAES128OFB {
+		stream, err := newStream(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt the decoded ciphertext: %v", err)
+		}
+		secret := buff[:length]
+		remaining := secret
+		for i := 0; i < len(shards) && len(remaining) > 0; i++ {
+			n := len(shards[i])
+			if n > len(remaining) {
+				n = len(remaining)
+			}
+			for from := 0; from < n; from += stripeSize {
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+				to := from + stripeSize
+				if to > n {
+					to = n
+				}
+				stream.XORKeyStream(remaining[from:to], shards[i][from:to])
+			}
+			remaining = remaining[n:]
+		}
+		return secret, nil
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	aead, err := h.suite.newAEAD(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt the decoded ciphertext: %v", err)
+	}
+	ciphertext := buff[: 0 : length+aead.Overhead()]
+	for i := 0; i < len(shards) && len(ciphertext) < cap(ciphertext); i++ {
+		n := len(shards[i])
+		if n > cap(ciphertext)-len(ciphertext) {
+			n = cap(ciphertext) - len(ciphertext)
+		}
+		ciphertext = append(ciphertext, shards[i][:n]...)
+	}
+	nonce := make([]byte, aead.NonceSize())
+	secret, err := aead.Open(ciphertext[:0], nonce, ciphertext, h.bytes())
+	if err != nil {
+		utils.Wipe(buff)
+		return nil, fmt.Errorf("failed to decrypt the decoded ciphertext: %v", err)
+	}
+	return secret, nil
+}
@@ -0,0 +1,78 @@
+package krawczyk
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Suite is the cipher suite used to encrypt the secret before encoding it
+// with reed-solomon. The suite is recorded in the header of every share,
+// so Combine does not need to know which suite was used in Split.
+type Suite byte
+
+const (
+	// SuiteAES128OFB is AES-128 in OFB mode without authentication,
+	// the original suite of this package and the default one.
+	SuiteAES128OFB Suite = iota
+	// SuiteAES128GCM is AES-128 in GCM mode.
+	SuiteAES128GCM
+	// SuiteAES256GCM is AES-256 in GCM mode.
+	SuiteAES256GCM
+	// SuiteChaCha20Poly1305 is ChaCha20-Poly1305 with 256-bit key.
+	SuiteChaCha20Poly1305
+
+	numSuites
+)
+
+// KeySize returns the key size of the suite in bytes, the size of the
+// shamir-shared metadata follows the key size.
+func (c Suite) KeySize() int {
+	switch c {
+	case SuiteAES128OFB, SuiteAES128GCM:
+		return 16
+	case SuiteAES256GCM, SuiteChaCha20Poly1305:
+		return 32
+	}
+	return 0
+}
+
+// Overhead returns the difference between the ciphertext and the
+// plaintext length, which is the size of the authentication tag.
+func (c Suite) Overhead() int {
+	if c == SuiteAES128OFB {
+		return 0
+	}
+	return 16
+}
+
+func (c Suite) String() string {
+	switch c {
+	case SuiteAES128OFB:
+		return "AES-128-OFB"
+	case SuiteAES128GCM:
+		return "AES-128-GCM"
+	case SuiteAES256GCM:
+		return "AES-256-GCM"
+	case SuiteChaCha20Poly1305:
+		return "ChaCha20-Poly1305"
+	}
+	return fmt.Sprintf("Suite(%d)", byte(c))
+}
+
+// newAEAD returns the authenticated cipher of the suite. The nonce can
+// always be zero since every secret is encrypted with a fresh key.
+func (c Suite) newAEAD(key []byte) (cipher.AEAD, error) {
+	switch c {
+	case SuiteAES128GCM, SuiteAES256GCM:
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	case SuiteChaCha20Poly1305:
+		return chacha20poly1305.New(key)
+	}
+	return nil, fmt.Errorf("%v is not an authenticated cipher suite", c)
+}
@@ -0,0 +1,184 @@
+// Package policy implements secret-sharing for general access structures,
+// described by monotone boolean policies such as
+//
+//	and(2of(a, b, c), 1of(d, e))
+//
+// which is satisfied by any two of a, b, c together with d or e. A policy
+// is a participant name, or a threshold gate over sub-policies: `kof(..)`
+// for any k of them, `and(..)` for all of them, and `or(..)` for any one of
+// them. The secret is split along the policy tree: an n-of-n gate XORs its
+// value with random pads, a 1-of-n gate replicates it, and the other gates
+// use shamir's secret-sharing.
+package policy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxChildren is the number of sub-policies of a gate, limited by the
+// number of shamir's shares.
+const maxChildren = 255
+
+// Policy is a parsed policy, either a participant (leaf) or a gate.
+type Policy struct {
+	// Name is the participant of a leaf, empty for a gate
+	Name string
+	// Threshold is the number of Children required by a gate
+	Threshold int
+	Children  []*Policy
+}
+
+// Parse parses a policy, such as `and(2of(a, b, c), or(d, e))`.
+// The names contain letters, digits, '_', '-', '.' and '@'.
+func Parse(s string) (*Policy, error) {
+	p := &parser{s: s}
+	policy, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if p.pos != len(p.s) {
+		return nil, fmt.Errorf("unexpected %q at offset %d", p.s[p.pos:], p.pos)
+	}
+	return policy, nil
+}
+
+// String returns the canonical form of the policy,
+// which is parsed back to the same policy.
+func (p *Policy) String() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	children := make([]string, len(p.Children))
+	for i, child := range p.Children {
+		children[i] = child.String()
+	}
+	return fmt.Sprintf("%dof(%s)", p.Threshold, strings.Join(children, ","))
+}
+
+// Participants returns the names of the participants, in order of their
+// first appearance in the policy.
+func (p *Policy) Participants() []string {
+	var names []string
+	seen := map[string]bool{}
+	p.walk(nil, func(leaf *Policy, _ []byte) {
+		if !seen[leaf.Name] {
+			seen[leaf.Name] = true
+			names = append(names, leaf.Name)
+		}
+	})
+	return names
+}
+
+// Satisfied reports whether the participants together satisfy the policy.
+func (p *Policy) Satisfied(names []string) bool {
+	present := map[string]bool{}
+	for _, name := range names {
+		present[name] = true
+	}
+	return p.satisfied(present)
+}
+
+func (p *Policy) satisfied(present map[string]bool) bool {
+	if p.Name != "" {
+		return present[p.Name]
+	}
+	count := 0
+	for _, child := range p.Children {
+		if child.satisfied(present) {
+			count++
+		}
+	}
+	return count >= p.Threshold
+}
+
+// walk calls fn on every leaf with its path, the indexes of the
+// children from the root down to the leaf.
+func (p *Policy) walk(path []byte, fn func(leaf *Policy, path []byte)) {
+	if p.Name != "" {
+		fn(p, path)
+		return
+	}
+	for i, child := range p.Children {
+		child.walk(append(path[:len(path):len(path)], byte(i)), fn)
+	}
+}
+
+type parser struct {
+	s   string
+	pos int
+}
+
+func (p *parser) skipSpaces() {
+	for p.pos < len(p.s) && strings.ContainsRune(" \t\r\n", rune(p.s[p.pos])) {
+		p.pos++
+	}
+}
+
+func isNameByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte("_-.@", c) >= 0
+}
+
+func (p *parser) parse() (*Policy, error) {
+	p.skipSpaces()
+	start := p.pos
+	for p.pos < len(p.s) && isNameByte(p.s[p.pos]) {
+		p.pos++
+	}
+	word := p.s[start:p.pos]
+	if word == "" {
+		return nil, fmt.Errorf("expecting a name or a gate at offset %d", start)
+	}
+	p.skipSpaces()
+	if p.pos == len(p.s) || p.s[p.pos] != '(' {
+		return &Policy{Name: word}, nil
+	}
+
+	// a gate, parse its children
+	p.pos++
+	var children []*Policy
+	for {
+		child, err := p.parse()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+		p.skipSpaces()
+		if p.pos == len(p.s) {
+			return nil, fmt.Errorf("missing ')' of the gate %q at offset %d", word, start)
+		}
+		if p.s[p.pos] == ')' {
+			p.pos++
+			break
+		}
+		if p.s[p.pos] != ',' {
+			return nil, fmt.Errorf("expecting ',' or ')' at offset %d", p.pos)
+		}
+		p.pos++
+	}
+	if len(children) > maxChildren {
+		return nil, fmt.Errorf("the gate %q at offset %d has more than %d children", word, start, maxChildren)
+	}
+
+	var threshold int
+	switch {
+	case word == "and":
+		threshold = len(children)
+	case word == "or":
+		threshold = 1
+	case strings.HasSuffix(word, "of"):
+		k, err := strconv.Atoi(strings.TrimSuffix(word, "of"))
+		if err != nil {
+			return nil, fmt.Errorf("unknown gate %q at offset %d", word, start)
+		}
+		threshold = k
+	default:
+		return nil, fmt.Errorf("unknown gate %q at offset %d", word, start)
+	}
+	if threshold < 1 || threshold > len(children) {
+		return nil, fmt.Errorf("the gate %q at offset %d requires %d of %d children", word, start, threshold, len(children))
+	}
+	return &Policy{Threshold: threshold, Children: children}, nil
+}
@@ -0,0 +1,113 @@
+package policy
+
+import (
+	"github.com/fadhilkurnia/shamir/csprng"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := map[string]string{
+		"a":                                "a",
+		"and(2of(a, b, c), 1of(d, e))":     "2of(2of(a,b,c),1of(d,e))",
+		" or( a ,b ) ":                     "1of(a,b)",
+		"3of(a,b,c,d)":                     "3of(a,b,c,d)",
+		"and(x@org, or(y.1, and(z_, w-)))": "2of(x@org,1of(y.1,2of(z_,w-)))",
+	}
+	for s, expected := range cases {
+		p, err := Parse(s)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", s, err)
+		}
+		if p.String() != expected {
+			t.Errorf("The parsed policy is different. Expected: '%v', but got '%v'.\n", expected, p.String())
+		}
+	}
+
+	for _, s := range []string{"", "and()", "and(a,", "and(a b)", "4of(a,b,c)", "0of(a)", "xof(a)", "foo(a)", "a)", "(a)"} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("expecting an error when parsing %q", s)
+		}
+	}
+}
+
+func TestSatisfied(t *testing.T) {
+	p, err := Parse("and(2of(a, b, c), or(d, e))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(p.Participants(), []string{"a", "b", "c", "d", "e"}) {
+		t.Errorf("unexpected participants %v", p.Participants())
+	}
+	cases := []struct {
+		names     []string
+		satisfied bool
+	}{
+		{[]string{"a", "b", "d"}, true},
+		{[]string{"c", "a", "e"}, true},
+		{[]string{"a", "b", "c"}, false},
+		{[]string{"a", "d", "e"}, false},
+		{nil, false},
+	}
+	for _, c := range cases {
+		if p.Satisfied(c.names) != c.satisfied {
+			t.Errorf("expecting Satisfied(%v) to be %v", c.names, c.satisfied)
+		}
+	}
+}
+
+func TestSplitCombinePolicy(t *testing.T) {
+	secretMsg := []byte("The quick brown fox jumps over the lazy dog")
+	randomizer := csprng.NewCSPRNG()
+
+	for _, policy := range []string{
+		"and(2of(a, b, c), 1of(d, e))",
+		"3of(a,b,c,d)",
+		"or(a, and(b, c))",
+		"2of(and(a, b), and(b, c), 2of(a, c, d))",
+	} {
+		p, _ := Parse(policy)
+		bundles, err := SplitPolicyWithRandomizer(secretMsg, policy, randomizer)
+		if err != nil {
+			t.Fatalf("failed to split with %q: %v", policy, err)
+		}
+		names := p.Participants()
+		if len(bundles) != len(names) {
+			t.Fatalf("expecting %d bundles, but got %d", len(names), len(bundles))
+		}
+
+		// try all the subsets of the participants
+		for set := 1; set < 1<<len(names); set++ {
+			var present []string
+			var presented [][]byte
+			for i, name := range names {
+				if set&(1<<i) != 0 {
+					present = append(present, name)
+					presented = append(presented, bundles[name])
+				}
+			}
+			combined, err := CombinePolicy(presented)
+			if !p.Satisfied(present) {
+				if err != ErrNotSatisfied {
+					t.Errorf("expecting ErrNotSatisfied for %v with %q, but got %v", present, policy, err)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("failed to combine %v with %q: %v", present, policy, err)
+			}
+			if !reflect.DeepEqual(secretMsg, combined) {
+				t.Errorf("The combined secret is different. Expected: '%v', but got '%v'.\n", string(secretMsg), string(combined))
+			}
+		}
+	}
+
+	bundles, _ := SplitPolicy(secretMsg, "and(a, b)")
+	other, _ := SplitPolicy(secretMsg, "or(a, b)")
+	if _, err := CombinePolicy([][]byte{bundles["a"], other["b"]}); err == nil {
+		t.Errorf("expecting an error when combining bundles of different policies")
+	}
+	if _, err := CombinePolicy([][]byte{bundles["a"][:len(bundles["a"])-1], bundles["b"]}); err == nil {
+		t.Errorf("expecting an error when combining a truncated bundle")
+	}
+}
@@ -0,0 +1,270 @@
+package policy
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/fadhilkurnia/shamir/csprng"
+	gf "github.com/fadhilkurnia/shamir/galois"
+	"github.com/fadhilkurnia/shamir/shamir"
+	"math/rand"
+)
+
+// ErrNotSatisfied is returned when the presented bundles do not satisfy the policy.
+var ErrNotSatisfied = errors.New("the bundles do not satisfy the policy")
+
+// piece is the value of a leaf of the policy tree, at its path.
+type piece struct {
+	path  []byte
+	share []byte
+}
+
+// SplitPolicy splits the secret along the policy, and returns the bundle of
+// each participant. A bundle holds the policy and the pieces of all the
+// leaves of the participant, so the bundles alone are enough for
+// CombinePolicy.
+func SplitPolicy(secret []byte, policy string) (map[string][]byte, error) {
+	return SplitPolicyWithRandomizer(secret, policy, nil)
+}
+
+// SplitPolicyWithRandomizer is exactly the same with SplitPolicy but with
+// randomizer provided by the caller. When randomizer is nil, math/rand is used.
+func SplitPolicyWithRandomizer(secret []byte, policy string, randomizer *csprng.CSPRNG) (map[string][]byte, error) {
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("cannot split an empty secret")
+	}
+	p, err := Parse(policy)
+	if err != nil {
+		return nil, err
+	}
+	pieces := map[string][]piece{}
+	if err := p.split(secret, nil, randomizer, pieces); err != nil {
+		return nil, err
+	}
+
+	canonical := p.String()
+	if len(canonical) > 0xffff {
+		return nil, errors.New("the policy is longer than 65535 bytes")
+	}
+	bundles := make(map[string][]byte, len(pieces))
+	for name, namePieces := range pieces {
+		bundles[name] = encodeBundle(canonical, name, namePieces)
+	}
+	return bundles, nil
+}
+
+// split gives the value of the node at path to its children, down to the leaves.
+func (p *Policy) split(value []byte, path []byte, randomizer *csprng.CSPRNG, pieces map[string][]piece) error {
+	if len(path) > 255 {
+		return errors.New("the policy is nested more than 255 levels deep")
+	}
+	if p.Name != "" {
+		if len(p.Name) > 255 {
+			return fmt.Errorf("the name %q is longer than 255 bytes", p.Name)
+		}
+		pieces[p.Name] = append(pieces[p.Name], piece{path, value})
+		return nil
+	}
+
+	n := len(p.Children)
+	values := make([][]byte, n)
+	switch p.Threshold {
+	case 1:
+		// any child alone recovers the value
+		for i := range values {
+			values[i] = value
+		}
+	case n:
+		// all the children are required, the value is xor-ed with random pads
+		pads := make([]byte, (n-1)*len(value))
+		var err error
+		if randomizer != nil {
+			_, err = randomizer.Read(pads)
+		} else {
+			_, err = rand.Read(pads)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to generate the pads: %v", err)
+		}
+		last := append([]byte(nil), value...)
+		for i := 0; i < n-1; i++ {
+			values[i] = pads[i*len(value) : (i+1)*len(value)]
+			gf.AddVector(values[i], last)
+		}
+		values[n-1] = last
+	default:
+		xCoordinates := make([]byte, n)
+		for i := range xCoordinates {
+			xCoordinates[i] = byte(i + 1)
+		}
+		shares, err := shamir.SplitAt(value, xCoordinates, p.Threshold, randomizer)
+		if err != nil {
+			return err
+		}
+		values = shares
+	}
+
+	for i, child := range p.Children {
+		if err := child.split(values[i], append(path[:len(path):len(path)], byte(i)), randomizer, pieces); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CombinePolicy reconstructs the secret from the bundles of some of the
+// participants. ErrNotSatisfied is returned when the participants of the
+// bundles do not satisfy the policy.
+func CombinePolicy(bundles [][]byte) ([]byte, error) {
+	if len(bundles) == 0 {
+		return nil, errors.New("no bundle is given")
+	}
+	var policy string
+	var names []string
+	pieces := map[string][]byte{}
+	for i, bundle := range bundles {
+		bundlePolicy, name, bundlePieces, err := decodeBundle(bundle)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bundle %d: %v", i, err)
+		}
+		if i == 0 {
+			policy = bundlePolicy
+		} else if bundlePolicy != policy {
+			return nil, errors.New("all the bundles must be split with the same policy")
+		}
+		names = append(names, name)
+		for _, pc := range bundlePieces {
+			pieces[string(pc.path)] = pc.share
+		}
+	}
+	p, err := Parse(policy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy in the bundles: %v", err)
+	}
+	if !p.Satisfied(names) {
+		return nil, ErrNotSatisfied
+	}
+
+	secret, ok, err := p.combine(nil, pieces)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("the bundles are missing pieces of their participants")
+	}
+	return secret, nil
+}
+
+// combine recovers the value of the node at path from the pieces of the
+// leaves, ok is false when not enough children are recovered.
+func (p *Policy) combine(path []byte, pieces map[string][]byte) ([]byte, bool, error) {
+	if p.Name != "" {
+		value, ok := pieces[string(path)]
+		return value, ok, nil
+	}
+
+	var values [][]byte
+	for i, child := range p.Children {
+		value, ok, err := child.combine(append(path[:len(path):len(path)], byte(i)), pieces)
+		if err != nil {
+			return nil, false, err
+		}
+		if ok {
+			values = append(values, value)
+		}
+		if len(values) == p.Threshold {
+			break
+		}
+	}
+	if len(values) < p.Threshold {
+		return nil, false, nil
+	}
+
+	switch p.Threshold {
+	case 1:
+		return values[0], true, nil
+	case len(p.Children):
+		value := append([]byte(nil), values[0]...)
+		for _, v := range values[1:] {
+			if len(v) != len(value) {
+				return nil, false, errors.New("the pieces of an and gate have different lengths")
+			}
+			gf.AddVector(v, value)
+		}
+		return value, true, nil
+	}
+	value, err := shamir.Combine(values)
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// encodeBundle encodes the pieces of a participant as
+// {len(policy) uint16, policy, len(name) uint8, name, #pieces uint16, pieces}
+// where each piece is {len(path) uint8, path, len(share) uint32, share}.
+func encodeBundle(policy, name string, pieces []piece) []byte {
+	size := 2 + len(policy) + 1 + len(name) + 2
+	for _, pc := range pieces {
+		size += 1 + len(pc.path) + 4 + len(pc.share)
+	}
+	bundle := make([]byte, size)
+	binary.LittleEndian.PutUint16(bundle, uint16(len(policy)))
+	offset := 2
+	offset += copy(bundle[offset:], policy)
+	bundle[offset] = byte(len(name))
+	offset++
+	offset += copy(bundle[offset:], name)
+	binary.LittleEndian.PutUint16(bundle[offset:], uint16(len(pieces)))
+	offset += 2
+	for _, pc := range pieces {
+		bundle[offset] = byte(len(pc.path))
+		offset++
+		offset += copy(bundle[offset:], pc.path)
+		binary.LittleEndian.PutUint32(bundle[offset:], uint32(len(pc.share)))
+		offset += 4
+		offset += copy(bundle[offset:], pc.share)
+	}
+	return bundle
+}
+
+// decodeBundle is the reverse of encodeBundle, the pieces refer to the bundle.
+func decodeBundle(bundle []byte) (policy, name string, pieces []piece, err error) {
+	errTruncated := errors.New("the bundle is truncated")
+	if len(bundle) < 2 {
+		return "", "", nil, errTruncated
+	}
+	offset := 2 + int(binary.LittleEndian.Uint16(bundle))
+	if len(bundle) < offset+1 {
+		return "", "", nil, errTruncated
+	}
+	policy = string(bundle[2:offset])
+	nameEnd := offset + 1 + int(bundle[offset])
+	if len(bundle) < nameEnd+2 {
+		return "", "", nil, errTruncated
+	}
+	name = string(bundle[offset+1 : nameEnd])
+	numPieces := int(binary.LittleEndian.Uint16(bundle[nameEnd:]))
+	offset = nameEnd + 2
+
+	pieces = make([]piece, numPieces)
+	for i := range pieces {
+		if len(bundle) < offset+1 {
+			return "", "", nil, errTruncated
+		}
+		pathEnd := offset + 1 + int(bundle[offset])
+		if len(bundle) < pathEnd+4 {
+			return "", "", nil, errTruncated
+		}
+		shareLen := int(binary.LittleEndian.Uint32(bundle[pathEnd:]))
+		if shareLen < 0 || len(bundle)-(pathEnd+4) < shareLen {
+			return "", "", nil, errTruncated
+		}
+		pieces[i] = piece{bundle[offset+1 : pathEnd], bundle[pathEnd+4 : pathEnd+4+shareLen]}
+		offset = pathEnd + 4 + shareLen
+	}
+	if offset != len(bundle) {
+		return "", "", nil, fmt.Errorf("%d trailing bytes in the bundle", len(bundle)-offset)
+	}
+	return policy, name, pieces, nil
+}
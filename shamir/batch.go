@@ -0,0 +1,164 @@
+package shamir
+
+import (
+	"fmt"
+	"github.com/fadhilkurnia/shamir/csprng"
+	"math/rand"
+)
+
+// SplitBatch secret-shares every secret into `parts` shares, `threshold` of
+// which are required to reconstruct it, as if Split were called on each
+// secret. The polynomials of all the secrets are laid out in a single
+// coefficient matrix and the shares of all the secrets are allocated at
+// once, amortizing the per-call costs when splitting many small secrets.
+// The shares of secrets[i] are returned at index i.
+//
+// Like Split, every secret gets its own random x coordinates, so the
+// polynomials are still evaluated once per secret for each x coordinate:
+// SplitBatch does not amortize the evaluation. SplitBatchAt is the fast
+// path, doing a single vectorized evaluation for each x coordinate over
+// the whole batch, when the secrets can share their x coordinates.
+func SplitBatch(secrets [][]byte, parts, threshold int) ([][][]byte, error) {
+	return SplitBatchWithRandomizer(secrets, parts, threshold, nil)
+}
+
+// SplitBatchWithRandomizer is exactly the same with SplitBatch but with
+// randomizer provided by the caller. When randomizer is nil, math/rand is used.
+func SplitBatchWithRandomizer(secrets [][]byte, parts, threshold int, randomizer *csprng.CSPRNG) ([][][]byte, error) {
+	if err := checkBatch(secrets, parts, threshold); err != nil {
+		return nil, err
+	}
+
+	// Generate random list of x coordinates for each secret, the random
+	// bytes used to shuffle the x coordinates are replaced in place
+	xBuff := make([]byte, parts*len(secrets))
+	if randomizer != nil {
+		_, _ = randomizer.Read(xBuff)
+	} else {
+		_, _ = rand.Read(xBuff)
+	}
+	xCoordinates := make([][]byte, len(secrets))
+	for i := range secrets {
+		xCoordinates[i] = xBuff[i*parts : (i+1)*parts : (i+1)*parts]
+		shuffleXCoordinates(xCoordinates[i], xCoordinates[i])
+	}
+
+	return splitBatch(secrets, xCoordinates, threshold, randomizer)
+}
+
+// SplitBatchAt is similar with SplitBatch, but the shares of all the secrets
+// are generated at the given x coordinates. Since all the polynomials are
+// evaluated at the same points, a single vectorized evaluation is done for
+// each x coordinate over the whole batch.
+func SplitBatchAt(secrets [][]byte, xCoordinates []byte, threshold int, randomizer *csprng.CSPRNG) ([][][]byte, error) {
+	if err := checkBatch(secrets, len(xCoordinates), threshold); err != nil {
+		return nil, err
+	}
+	if err := checkXCoordinates(xCoordinates); err != nil {
+		return nil, err
+	}
+
+	return splitBatch(secrets, [][]byte{xCoordinates}, threshold, randomizer)
+}
+
+func checkBatch(secrets [][]byte, parts, threshold int) error {
+	// Sanity check the input
+	if parts < threshold {
+		return fmt.Errorf("parts cannot be less than threshold")
+	}
+	if parts > 255 {
+		return fmt.Errorf("parts cannot exceed 255")
+	}
+	if threshold < 2 {
+		return fmt.Errorf("threshold must be at least 2")
+	}
+	if threshold > 255 {
+		return fmt.Errorf("threshold cannot exceed 255")
+	}
+	if len(secrets) == 0 {
+		return fmt.Errorf("cannot split an empty batch")
+	}
+	for _, secret := range secrets {
+		if len(secret) == 0 {
+			return fmt.Errorf("cannot split an empty secret")
+		}
+	}
+	return nil
+}
+
+// splitBatch generates the shares of the secrets, either each secret has
+// its own x coordinates, or a single set of x coordinates is shared by
+// all the secrets. The inputs are assumed to be already checked.
+func splitBatch(secrets [][]byte, xCoordinates [][]byte, threshold int, randomizer *csprng.CSPRNG) ([][][]byte, error) {
+	parts := len(xCoordinates[0])
+	degree := threshold - 1
+	N := 0
+	for _, secret := range secrets {
+		N += len(secret)
+	}
+
+	// The coefficient matrix has (degree+1) rows of N columns, row d holds
+	// the d-th coefficient of the polynomials of all the secrets, thus
+	// row 0 is the concatenation of the secrets.
+	coefficients, putCoefficients := getScratch((degree + 1) * N)
+	defer putCoefficients()
+	var err error
+	if randomizer != nil {
+		_, err = randomizer.Read(coefficients[N:])
+	} else {
+		_, err = rand.Read(coefficients[N:])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate polynomial: %v", err)
+	}
+	offsets := make([]int, len(secrets)+1)
+	for i, secret := range secrets {
+		copy(coefficients[offsets[i]:], secret)
+		offsets[i+1] = offsets[i] + len(secret)
+	}
+
+	// Allocate the shares of all the secrets at once, each share
+	// is {y1, y2, .., yN, x} as in Split
+	out := make([][][]byte, len(secrets))
+	outShares := make([][]byte, len(secrets)*parts)
+	buff := make([]byte, (N+len(secrets))*parts)
+	for i, secret := range secrets {
+		xs := xCoordinates[0]
+		if len(xCoordinates) > 1 {
+			xs = xCoordinates[i]
+		}
+		out[i] = outShares[i*parts : (i+1)*parts]
+		for j := 0; j < parts; j++ {
+			out[i][j] = buff[: len(secret)+1 : len(secret)+1]
+			out[i][j][len(secret)] = xs[j]
+			buff = buff[len(secret)+1:]
+		}
+	}
+
+	// each secret has its own x coordinates, evaluate its polynomials
+	// directly into its shares
+	if len(xCoordinates) > 1 {
+		for i := range secrets {
+			for j := 0; j < parts; j++ {
+				evaluateColumnsAt(coefficients, N, offsets[i], offsets[i+1], xCoordinates[i][j], out[i][j])
+			}
+		}
+		return out, nil
+	}
+
+	// the x coordinates are shared, evaluate all the polynomials
+	// at once for each x coordinate, then scatter the results
+	resultBytesBuff := bPool.Get()
+	defer bPool.Put(resultBytesBuff)
+	resultBytesBuff.Reset()
+	resultBytesBuff.Grow(N)
+	result := resultBytesBuff.Bytes()[0:N]
+	for j := 0; j < parts; j++ {
+		evaluateColumnsAt(coefficients, N, 0, N, xCoordinates[0][j], result)
+		for i := range secrets {
+			copy(out[i][j], result[offsets[i]:offsets[i+1]])
+		}
+	}
+
+	return out, nil
+}
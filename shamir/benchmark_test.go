@@ -112,4 +112,24 @@ func BenchmarkSplitWithRandomizer(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		_, _ = SplitWithRandomizer(bytes1M, 4, 2, r)
 	}
-}
\ No newline at end of file
+}
+
+func BenchmarkSplitRamp(b *testing.B) {
+	r := csprng.NewCSPRNG()
+	b.SetBytes(int64(len(bytes1M)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = SplitRampWithRandomizer(bytes1M, 8, 2, 4, r)
+	}
+}
+
+func BenchmarkCombineRamp(b *testing.B) {
+	shares, _ := SplitRamp(bytes1M, 8, 2, 4)
+	b.SetBytes(int64(len(bytes1M)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = CombineRamp(shares[2:])
+	}
+}
@@ -0,0 +1,175 @@
+package shamir
+
+import (
+	"container/list"
+	"fmt"
+	gf "github.com/fadhilkurnia/shamir/galois"
+	"sync"
+)
+
+// Combiner reconstructs secrets from the shares of a fixed set of x
+// coordinates. The Lagrange basis at x=0 is computed once, so combining
+// is a single weighted sum of the shares using the vector kernels.
+// A Combiner is safe for concurrent use.
+type Combiner struct {
+	xCoordinates []byte
+	weights      []byte
+
+	// index[x] is the position of x in xCoordinates plus one,
+	// zero for the x coordinates not in the set
+	index [256]int
+}
+
+// NewCombiner creates a Combiner for the shares at the given x coordinates,
+// the x coordinates must be unique and non-zero.
+func NewCombiner(xCoordinates []byte) (*Combiner, error) {
+	if len(xCoordinates) < 2 {
+		return nil, fmt.Errorf("less than two parts cannot be used to reconstruct the secret")
+	}
+	if err := checkXCoordinates(xCoordinates); err != nil {
+		return nil, err
+	}
+	return newCombiner(xCoordinates), nil
+}
+
+// newCombiner assumes the x coordinates are unique.
+func newCombiner(xCoordinates []byte) *Combiner {
+	c := &Combiner{
+		xCoordinates: append([]byte(nil), xCoordinates...),
+		weights:      lagrangeWeightsAt(xCoordinates, 0),
+	}
+	for i, x := range c.xCoordinates {
+		c.index[x] = i + 1
+	}
+	return c
+}
+
+// XCoordinates returns the x coordinates of the Combiner.
+func (c *Combiner) XCoordinates() []byte {
+	return append([]byte(nil), c.xCoordinates...)
+}
+
+// Combine reconstructs the secret from one share for each x coordinate
+// of the Combiner, in any order.
+func (c *Combiner) Combine(parts [][]byte) ([]byte, error) {
+	if len(parts) != len(c.xCoordinates) {
+		return nil, fmt.Errorf("expecting %d parts, but got %d", len(c.xCoordinates), len(parts))
+	}
+	if err := checkParts(parts); err != nil {
+		return nil, err
+	}
+	secret := make([]byte, len(parts[0])-1)
+	if err := c.combine(parts, secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// CombineBatch reconstructs many secrets at once, shares[i] are the parts
+// of the i-th secret, given as in Combine.
+func (c *Combiner) CombineBatch(shares [][][]byte) ([][]byte, error) {
+	N := 0
+	for i, parts := range shares {
+		if len(parts) != len(c.xCoordinates) {
+			return nil, fmt.Errorf("expecting %d parts, but got %d for secret %d", len(c.xCoordinates), len(parts), i)
+		}
+		if err := checkParts(parts); err != nil {
+			return nil, fmt.Errorf("invalid parts for secret %d: %v", i, err)
+		}
+		N += len(parts[0]) - 1
+	}
+
+	// the secrets are allocated at once
+	buff := make([]byte, N)
+	secrets := make([][]byte, len(shares))
+	for i, parts := range shares {
+		n := len(parts[0]) - 1
+		secrets[i] = buff[:n:n]
+		buff = buff[n:]
+		if err := c.combine(parts, secrets[i]); err != nil {
+			return nil, fmt.Errorf("invalid parts for secret %d: %v", i, err)
+		}
+	}
+	return secrets, nil
+}
+
+// combine assumes the parts are already checked, and the secret is zeroed.
+func (c *Combiner) combine(parts [][]byte, secret []byte) error {
+	weights, err := c.weightsOf(parts)
+	if err != nil {
+		return err
+	}
+	combineStripe(parts, weights, secret, 0, len(secret))
+	return nil
+}
+
+// weightsOf returns the weight of each part, based on its x coordinate.
+func (c *Combiner) weightsOf(parts [][]byte) ([]byte, error) {
+	weights := make([]byte, len(parts))
+	for j, part := range parts {
+		x := part[len(part)-1]
+		i := c.index[x]
+		if i == 0 {
+			return nil, fmt.Errorf("unexpected x coordinate %d", x)
+		}
+		weights[j] = c.weights[i-1]
+	}
+	return weights, nil
+}
+
+// combineStripe reconstructs secret[from:to] as the weighted sum of the parts.
+func combineStripe(parts [][]byte, weights []byte, secret []byte, from, to int) {
+	for j, part := range parts {
+		gf.MulAddVector(weights[j], part[from:to], secret[from:to])
+	}
+}
+
+// combinerCacheSize is the number of recently used sets of
+// x coordinates for which Combine keeps the Combiner.
+const combinerCacheSize = 32
+
+// combinerCache is an LRU of the Combiners used by Combine,
+// keyed by the x coordinates in the order of the parts.
+type combinerCache struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type combinerCacheEntry struct {
+	key      string
+	combiner *Combiner
+}
+
+var combiners = &combinerCache{
+	entries: map[string]*list.Element{},
+	order:   list.New(),
+}
+
+// get returns the Combiner for the x coordinates, assumed to be unique.
+func (cc *combinerCache) get(xCoordinates []byte) *Combiner {
+	key := string(xCoordinates)
+	cc.mu.Lock()
+	if e, ok := cc.entries[key]; ok {
+		cc.order.MoveToFront(e)
+		cc.mu.Unlock()
+		return e.Value.(*combinerCacheEntry).combiner
+	}
+	cc.mu.Unlock()
+
+	c := newCombiner(xCoordinates)
+
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if e, ok := cc.entries[key]; ok {
+		cc.order.MoveToFront(e)
+		return e.Value.(*combinerCacheEntry).combiner
+	}
+	cc.entries[key] = cc.order.PushFront(&combinerCacheEntry{key, c})
+	if cc.order.Len() > combinerCacheSize {
+		oldest := cc.order.Back()
+		cc.order.Remove(oldest)
+		delete(cc.entries, oldest.Value.(*combinerCacheEntry).key)
+	}
+	return c
+}
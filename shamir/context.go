@@ -0,0 +1,96 @@
+package shamir
+
+import (
+	"context"
+	"fmt"
+	"github.com/fadhilkurnia/shamir/csprng"
+)
+
+// SplitContext is similar with Split, but the secret is split stripe by
+// stripe and ctx is checked between stripes, ctx.Err() is returned once
+// ctx is done.
+func SplitContext(ctx context.Context, secret []byte, parts, threshold int) ([][]byte, error) {
+	return SplitWithRandomizerContext(ctx, secret, parts, threshold, nil)
+}
+
+// SplitWithRandomizerContext is similar with SplitWithRandomizer, but ctx
+// is checked between stripes as in SplitContext.
+func SplitWithRandomizerContext(ctx context.Context, secret []byte, parts, threshold int, randomizer *csprng.CSPRNG) ([][]byte, error) {
+	s, err := NewSplitter(parts, threshold, WithXCoordinates(randomXCoordinates(parts, randomizer)))
+	if err != nil {
+		return nil, err
+	}
+	return s.SplitWithRandomizerContext(ctx, secret, randomizer)
+}
+
+// SplitContext is similar with Split, but ctx is checked between stripes,
+// see the package-level SplitContext.
+func (s *Splitter) SplitContext(ctx context.Context, secret []byte) ([][]byte, error) {
+	return s.SplitWithRandomizerContext(ctx, secret, nil)
+}
+
+// SplitWithRandomizerContext is similar with SplitWithRandomizer, but ctx
+// is checked between stripes, see the package-level SplitContext.
+func (s *Splitter) SplitWithRandomizerContext(ctx context.Context, secret []byte, randomizer *csprng.CSPRNG) ([][]byte, error) {
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("cannot split an empty secret")
+	}
+
+	out := newShares(len(secret), s.xCoordinates)
+	err := runStripes(len(secret), 1, func(_, from, to int) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return evaluateStripe(secret, from, to, s.powers, randomizer, out)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CombineContext is similar with Combine, but the secret is reconstructed
+// stripe by stripe and ctx is checked between stripes, ctx.Err() is
+// returned once ctx is done.
+func CombineContext(ctx context.Context, parts [][]byte) ([]byte, error) {
+	if err := checkParts(parts); err != nil {
+		return nil, err
+	}
+	xSamples := make([]uint8, len(parts))
+	for i, part := range parts {
+		xSamples[i] = part[len(part)-1]
+	}
+	return combiners.get(xSamples).combineContext(ctx, parts)
+}
+
+// CombineContext is similar with Combine, but ctx is checked between
+// stripes, see the package-level CombineContext.
+func (c *Combiner) CombineContext(ctx context.Context, parts [][]byte) ([]byte, error) {
+	if len(parts) != len(c.xCoordinates) {
+		return nil, fmt.Errorf("expecting %d parts, but got %d", len(c.xCoordinates), len(parts))
+	}
+	if err := checkParts(parts); err != nil {
+		return nil, err
+	}
+	return c.combineContext(ctx, parts)
+}
+
+// combineContext assumes the parts are already checked.
+func (c *Combiner) combineContext(ctx context.Context, parts [][]byte) ([]byte, error) {
+	weights, err := c.weightsOf(parts)
+	if err != nil {
+		return nil, err
+	}
+	secret := make([]byte, len(parts[0])-1)
+	err = runStripes(len(secret), 1, func(_, from, to int) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		combineStripe(parts, weights, secret, from, to)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
@@ -0,0 +1,138 @@
+package shamir
+
+import (
+	"fmt"
+	"github.com/fadhilkurnia/shamir/csprng"
+	gf "github.com/fadhilkurnia/shamir/galois"
+	"math/rand"
+)
+
+// Enrolling a new holder takes three steps, without reconstructing the
+// secret nor revealing a share to anyone:
+//  1. each participant, at least `threshold` existing holders, generates
+//     masks with NewEnrollmentMasks and sends one to every other participant,
+//  2. each participant computes its partial with EnrollmentPartial, from
+//     the masks it sent and received, and sends it to the new holder,
+//  3. the new holder adds the partials with EnrollmentCombine.
+// The partial of a participant is its share weighted by its Lagrange weight
+// at the new x coordinate, hidden by the masks, which cancel out in the sum.
+
+// NewEnrollmentMasks generates the random masks of the participant at x
+// coordinate self. masks[i] is sent to the holder of participants[i], and
+// is nil for the participant itself.
+// When randomizer is nil, math/rand is used.
+func NewEnrollmentMasks(participants []byte, self byte, secretLen int, randomizer *csprng.CSPRNG) ([][]byte, error) {
+	if err := checkParticipants(participants, self); err != nil {
+		return nil, err
+	}
+	if secretLen < 1 {
+		return nil, fmt.Errorf("cannot enroll with an empty secret")
+	}
+
+	buff := make([]byte, (len(participants)-1)*secretLen)
+	var err error
+	if randomizer != nil {
+		_, err = randomizer.Read(buff)
+	} else {
+		_, err = rand.Read(buff)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate the masks: %v", err)
+	}
+	masks := make([][]byte, len(participants))
+	for i, x := range participants {
+		if x == self {
+			continue
+		}
+		masks[i] = buff[:secretLen:secretLen]
+		buff = buff[secretLen:]
+	}
+	return masks, nil
+}
+
+// EnrollmentPartial computes the partial of the participant holding share
+// for the new holder at newX. masks are the masks the participant sent and
+// the masks it received, in any order. The partial is {p1, p2, .., pN, newX}.
+func EnrollmentPartial(share []byte, participants []byte, newX byte, masks [][]byte) ([]byte, error) {
+	if len(share) < 2 {
+		return nil, fmt.Errorf("the share must be at least two bytes")
+	}
+	N := len(share) - 1
+	if err := checkParticipants(participants, share[N]); err != nil {
+		return nil, err
+	}
+	self := 0
+	for i, x := range participants {
+		if x == newX || newX == 0 {
+			return nil, fmt.Errorf("invalid new x coordinate %d", newX)
+		}
+		if x == share[N] {
+			self = i
+		}
+	}
+	var numMasks int
+	for i, mask := range masks {
+		if mask == nil {
+			continue
+		}
+		if len(mask) != N {
+			return nil, fmt.Errorf("mask %d has %d bytes, but expecting %d bytes", i, len(mask), N)
+		}
+		numMasks++
+	}
+	if numMasks != 2*(len(participants)-1) {
+		return nil, fmt.Errorf("expecting %d masks sent and received, but got %d", 2*(len(participants)-1), numMasks)
+	}
+
+	partial := make([]byte, N+1)
+	weight := lagrangeWeightsAt(participants, newX)[self]
+	gf.MulAddVector(weight, share[:N], partial[:N])
+	for _, mask := range masks {
+		if mask != nil {
+			gf.AddVector(mask, partial[:N])
+		}
+	}
+	partial[N] = newX
+	return partial, nil
+}
+
+// EnrollmentCombine adds the partials of all the participants
+// into the share of the new holder.
+func EnrollmentCombine(partials [][]byte) ([]byte, error) {
+	if len(partials) < 2 {
+		return nil, fmt.Errorf("less than two partials cannot be used to enroll")
+	}
+	N := len(partials[0]) - 1
+	if N < 1 {
+		return nil, fmt.Errorf("partials must be at least two bytes")
+	}
+	share := make([]byte, N+1)
+	share[N] = partials[0][N]
+	for _, partial := range partials {
+		if len(partial) != N+1 {
+			return nil, fmt.Errorf("all partials must be the same length")
+		}
+		if partial[N] != share[N] {
+			return nil, fmt.Errorf("all partials must be for the same new x coordinate")
+		}
+		gf.AddVector(partial[:N], share[:N])
+	}
+	return share, nil
+}
+
+// checkParticipants verifies the x coordinates of the participants are
+// unique, non-zero, and include self.
+func checkParticipants(participants []byte, self byte) error {
+	if len(participants) < 2 {
+		return fmt.Errorf("less than two participants cannot enroll a new holder")
+	}
+	if err := checkXCoordinates(participants); err != nil {
+		return err
+	}
+	for _, x := range participants {
+		if x == self {
+			return nil
+		}
+	}
+	return fmt.Errorf("x coordinate %d is not a participant", self)
+}
@@ -0,0 +1,81 @@
+package shamir
+
+import (
+	"fmt"
+	gf "github.com/fadhilkurnia/shamir/galois"
+)
+
+// Shamir's secret-sharing is linear: the holders of the shares of several
+// secrets, split with the same threshold at the same x coordinates, can
+// compute the shares of a linear combination of the secrets without
+// reconstructing them. The arithmetic is byte-wise in GF(2^8), the sum of
+// two secrets is their XOR.
+
+// AddShares adds two shares at the same x coordinate, giving the share of
+// the XOR of their secrets at that x coordinate.
+func AddShares(a, b []byte) ([]byte, error) {
+	if err := checkSameX([][]byte{a, b}); err != nil {
+		return nil, err
+	}
+	sum := append([]byte(nil), b...)
+	gf.AddVector(a[:len(a)-1], sum[:len(sum)-1])
+	return sum, nil
+}
+
+// ScaleShare multiplies the share with the constant c,
+// giving the share of the secret multiplied with c.
+func ScaleShare(share []byte, c byte) ([]byte, error) {
+	if err := checkSameX([][]byte{share}); err != nil {
+		return nil, err
+	}
+	N := len(share) - 1
+	scaled := make([]byte, len(share))
+	// accumulate into the zeroed output, MulConstVector allocates on amd64
+	gf.MulAddVector(c, share[:N], scaled[:N])
+	scaled[N] = share[N]
+	return scaled, nil
+}
+
+// LinearCombination computes sum_i coeffs[i] * shares[i] of shares at the
+// same x coordinate, giving the share of the same linear combination of
+// their secrets.
+func LinearCombination(shares [][]byte, coeffs []byte) ([]byte, error) {
+	if len(shares) != len(coeffs) {
+		return nil, fmt.Errorf("expecting %d coefficients, but got %d", len(shares), len(coeffs))
+	}
+	if err := checkSameX(shares); err != nil {
+		return nil, err
+	}
+	N := len(shares[0]) - 1
+	result := make([]byte, N+1)
+	for i, share := range shares {
+		gf.MulAddVector(coeffs[i], share[:N], result[:N])
+	}
+	result[N] = shares[0][N]
+	return result, nil
+}
+
+// checkSameX ensures the shares have the same length
+// and the same non-zero x coordinate.
+func checkSameX(shares [][]byte) error {
+	if len(shares) == 0 {
+		return fmt.Errorf("no share is given")
+	}
+	shareLen := len(shares[0])
+	if shareLen < 2 {
+		return fmt.Errorf("shares must be at least two bytes")
+	}
+	x := shares[0][shareLen-1]
+	if x == 0 {
+		return fmt.Errorf("x coordinate cannot be zero")
+	}
+	for _, share := range shares[1:] {
+		if len(share) != shareLen {
+			return fmt.Errorf("all shares must be the same length")
+		}
+		if share[shareLen-1] != x {
+			return fmt.Errorf("all shares must be at the same x coordinate, but got %d and %d", x, share[shareLen-1])
+		}
+	}
+	return nil
+}
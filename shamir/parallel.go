@@ -0,0 +1,178 @@
+package shamir
+
+import (
+	"fmt"
+	"github.com/fadhilkurnia/shamir/csprng"
+	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// stripeSize is the number of secret bytes processed by a goroutine at
+// once in the parallel mode, small enough for the stripe of the secret
+// and of the shares to stay in the cache.
+const stripeSize = 256 << 10
+
+// runStripes calls fn for every stripe of [0, N) on at most `goroutines`
+// goroutines, g is the index of the goroutine running fn. The first
+// error returned by fn stops all the goroutines and is returned.
+func runStripes(N, goroutines int, fn func(g, from, to int) error) error {
+	numStripes := (N + stripeSize - 1) / stripeSize
+	if goroutines > numStripes {
+		goroutines = numStripes
+	}
+	if goroutines <= 1 {
+		for from := 0; from < N; from += stripeSize {
+			to := from + stripeSize
+			if to > N {
+				to = N
+			}
+			if err := fn(0, from, to); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var next int64
+	var failed int32
+	errs := make([]error, goroutines)
+	wg := sync.WaitGroup{}
+	wg.Add(goroutines)
+	for g := 0; g < goroutines; g++ {
+		go func(g int) {
+			defer wg.Done()
+			for atomic.LoadInt32(&failed) == 0 {
+				i := int(atomic.AddInt64(&next, 1) - 1)
+				if i >= numStripes {
+					return
+				}
+				to := (i + 1) * stripeSize
+				if to > N {
+					to = N
+				}
+				if err := fn(g, i*stripeSize, to); err != nil {
+					errs[g] = err
+					atomic.StoreInt32(&failed, 1)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newRandomizers derives an independent randomizer for each goroutine,
+// since a randomizer is not safe for concurrent use. The randomizer is
+// used to seed them when it is not nil, otherwise math/rand is used.
+func newRandomizers(goroutines int, randomizer *csprng.CSPRNG) ([]*csprng.CSPRNG, error) {
+	keyIvs := make([]byte, 32*goroutines)
+	var err error
+	if randomizer != nil {
+		_, err = randomizer.Read(keyIvs)
+	} else {
+		_, err = rand.Read(keyIvs)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to seed the randomizers: %v", err)
+	}
+	randomizers := make([]*csprng.CSPRNG, goroutines)
+	for g := range randomizers {
+		randomizers[g] = csprng.NewCSPRNGWithKeyIV(keyIvs[32*g : 32*(g+1)])
+	}
+	return randomizers, nil
+}
+
+// splitWithPowersP is the parallel version of splitWithPowers, the secret
+// is split stripe by stripe on at most GOMAXPROCS goroutines, each with
+// its own randomizer.
+func splitWithPowersP(secret []byte, xCoordinates []byte, powers [][]byte, randomizer *csprng.CSPRNG) ([][]byte, error) {
+	goroutines := runtime.GOMAXPROCS(0)
+	if len(secret) <= stripeSize || goroutines == 1 {
+		return splitWithPowers(secret, xCoordinates, powers, randomizer)
+	}
+
+	randomizers, err := newRandomizers(goroutines, randomizer)
+	if err != nil {
+		return nil, err
+	}
+	out := newShares(len(secret), xCoordinates)
+	err = runStripes(len(secret), goroutines, func(g, from, to int) error {
+		return evaluateStripe(secret, from, to, powers, randomizers[g], out)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SplitWithRandomizerP is the parallel version of SplitWithRandomizer, for
+// large secrets. The secret is split in stripes on at most GOMAXPROCS
+// goroutines, each with its own randomizer seeded from the given one.
+func SplitWithRandomizerP(secret []byte, parts, threshold int, randomizer *csprng.CSPRNG) ([][]byte, error) {
+	s, err := NewSplitter(parts, threshold, WithXCoordinates(randomXCoordinates(parts, randomizer)))
+	if err != nil {
+		return nil, err
+	}
+	return s.SplitWithRandomizerP(secret, randomizer)
+}
+
+// SplitP is the parallel version of Split, see SplitWithRandomizerP.
+func (s *Splitter) SplitP(secret []byte) ([][]byte, error) {
+	return s.SplitWithRandomizerP(secret, nil)
+}
+
+// SplitWithRandomizerP is the parallel version of SplitWithRandomizer,
+// see the package-level SplitWithRandomizerP.
+func (s *Splitter) SplitWithRandomizerP(secret []byte, randomizer *csprng.CSPRNG) ([][]byte, error) {
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("cannot split an empty secret")
+	}
+	return splitWithPowersP(secret, s.xCoordinates, s.powers, randomizer)
+}
+
+// CombineP is the parallel version of Combine, for large secrets. The
+// secret is reconstructed in stripes on at most GOMAXPROCS goroutines.
+func CombineP(parts [][]byte) ([]byte, error) {
+	if err := checkParts(parts); err != nil {
+		return nil, err
+	}
+	xSamples := make([]uint8, len(parts))
+	for i, part := range parts {
+		xSamples[i] = part[len(part)-1]
+	}
+	return combiners.get(xSamples).combineP(parts)
+}
+
+// CombineP is the parallel version of Combine, see the package-level CombineP.
+func (c *Combiner) CombineP(parts [][]byte) ([]byte, error) {
+	if len(parts) != len(c.xCoordinates) {
+		return nil, fmt.Errorf("expecting %d parts, but got %d", len(c.xCoordinates), len(parts))
+	}
+	if err := checkParts(parts); err != nil {
+		return nil, err
+	}
+	return c.combineP(parts)
+}
+
+// combineP assumes the parts are already checked.
+func (c *Combiner) combineP(parts [][]byte) ([]byte, error) {
+	weights, err := c.weightsOf(parts)
+	if err != nil {
+		return nil, err
+	}
+	secret := make([]byte, len(parts[0])-1)
+	_ = runStripes(len(secret), runtime.GOMAXPROCS(0), func(g, from, to int) error {
+		combineStripe(parts, weights, secret, from, to)
+		return nil
+	})
+	return secret, nil
+}
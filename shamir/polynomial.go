@@ -8,10 +8,11 @@ import (
 	"math/rand"
 )
 
+// polynomialBufferPool keeps the evaluation scratch, wiped when put back.
 var polynomialBufferPool *utils.BytesBufferPool
 
 func init() {
-	polynomialBufferPool = utils.NewBytesBufferPool(0)
+	polynomialBufferPool = utils.NewSecureBytesBufferPool(0)
 }
 
 func makePolynomialsWithBuff(intercepts []uint8, degree int, buffer []uint8) ([]uint8, error) {
@@ -70,6 +71,7 @@ func makePolynomials(intercepts []uint8, degree int) ([][]uint8, error) {
 		copy(polynomials[p][1:], coefficients[startIdx:startIdx+degree]) // polynomials[p][1:] is the other coefficients
 		startIdx += degree
 	}
+	utils.Wipe(coefficients)
 
 	return polynomials, nil
 }
@@ -173,6 +175,21 @@ func evaluatePolynomialsAtWithCoefficientsBuffer(coefficientsBuff []uint8, rowLe
 	copy(out[:rowLen], result)
 }
 
+// evaluateColumnsAt evaluates the polynomials in columns [from, to) of the
+// coefficient matrix at x, writing the results into out. The coefficient
+// matrix has rowLen columns, row d holds the d-th coefficients. Instead of
+// Horner's method, each row is multiplied by the power of x and added into
+// out, so no temporary buffer is needed.
+func evaluateColumnsAt(coefficients []uint8, rowLen, from, to int, x uint8, out []uint8) {
+	out = out[:to-from]
+	copy(out, coefficients[from:to])
+	xPow := uint8(1)
+	for s := rowLen; s < len(coefficients); s += rowLen {
+		xPow = gf.GalMultiply(xPow, x)
+		gf.MulAddVector(xPow, coefficients[s+from:s+to], out)
+	}
+}
+
 // genericEvaluatePolynomialsAt assumes x is not 0.
 // coefficients is a ((degree+1)xN) matrix
 func genericEvaluatePolynomialsAt(coefficients [][]uint8, x uint8, out []uint8) {
@@ -225,6 +242,9 @@ func div(a, b uint8) uint8 {
 		// should never happen, hence the panic
 		panic("divide by zero")
 	}
+	if gf.ConstantTime() {
+		return gf.GalDivideCT(a, b)
+	}
 
 	log_a := logTable[a]
 	log_b := logTable[b]
@@ -239,6 +259,9 @@ func div(a, b uint8) uint8 {
 
 // mult multiplies two numbers in GF(2^8)
 func mult(a, b uint8) (out uint8) {
+	if gf.ConstantTime() {
+		return gf.GalMultiplyCT(a, b)
+	}
 	log_a := logTable[a]
 	log_b := logTable[b]
 	sum := (int(log_a) + int(log_b)) % 255
@@ -291,3 +314,23 @@ func interpolatePolynomial(x_samples, y_samples []uint8, x uint8) uint8 {
 	}
 	return result
 }
+
+// lagrangeWeightsAt returns the Lagrange basis of the x samples at x,
+// the value at x of the polynomial passing through the samples is the
+// sum of the y samples multiplied with their weights:
+// w_i = prod_{j!=i} (x + x_j) / (x_i + x_j)
+func lagrangeWeightsAt(x_samples []uint8, x uint8) []uint8 {
+	weights := make([]uint8, len(x_samples))
+	for i := range x_samples {
+		weights[i] = 1
+		for j := range x_samples {
+			if i == j {
+				continue
+			}
+			num := add(x, x_samples[j])
+			denom := add(x_samples[i], x_samples[j])
+			weights[i] = mult(weights[i], div(num, denom))
+		}
+	}
+	return weights
+}
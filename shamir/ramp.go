@@ -0,0 +1,169 @@
+package shamir
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/fadhilkurnia/shamir/csprng"
+	gf "github.com/fadhilkurnia/shamir/galois"
+	"math/rand"
+)
+
+// RampShareOverhead is the byte size of the trailer of a ramp share: the
+// secret length (uint32), the privacy threshold, the packing, and the
+// x coordinate.
+const RampShareOverhead = 7
+
+// rampSecretX returns the x coordinate holding the l-th packed secret
+// stripe: 0, 255, 254, .. so the shares are at 1..256-packing.
+func rampSecretX(l int) byte {
+	return byte(256 - l)
+}
+
+// SplitRamp splits the secret with a ramp scheme, packing `packing` secret
+// bytes into each polynomial: any `privacy` shares reveal nothing about the
+// secret, and any `privacy+packing` shares reconstruct it with CombineRamp.
+// The shares are about 1/packing of the secret, plus RampShareOverhead.
+//
+// The secret is cut into `packing` stripes, the l-th stripe is the value of
+// the polynomials at rampSecretX(l). The polynomials have degree
+// privacy+packing-1, the values of `privacy` of the shares are random and
+// the others are interpolated from them and the stripes.
+func SplitRamp(secret []byte, parts, privacy, packing int) ([][]byte, error) {
+	return SplitRampWithRandomizer(secret, parts, privacy, packing, nil)
+}
+
+// SplitRampWithRandomizer is exactly the same with SplitRamp but with
+// randomizer provided by the caller. When randomizer is nil, math/rand is used.
+func SplitRampWithRandomizer(secret []byte, parts, privacy, packing int, randomizer *csprng.CSPRNG) ([][]byte, error) {
+	threshold := privacy + packing
+	if privacy < 1 {
+		return nil, fmt.Errorf("privacy threshold must be at least 1")
+	}
+	if packing < 1 {
+		return nil, fmt.Errorf("packing must be at least 1")
+	}
+	if parts < threshold {
+		return nil, fmt.Errorf("parts cannot be less than privacy+packing")
+	}
+	if parts > 256-packing {
+		return nil, fmt.Errorf("parts cannot exceed %d with packing %d", 256-packing, packing)
+	}
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("cannot split an empty secret")
+	}
+	if uint64(len(secret)) > uint64(^uint32(0)) {
+		return nil, fmt.Errorf("the secret cannot exceed 4 GB")
+	}
+
+	// the x coordinates of the shares, avoiding the x of the stripes
+	xCoordinates := make([]byte, parts)
+	if randomizer != nil {
+		_, _ = randomizer.Read(xCoordinates)
+	} else {
+		_, _ = rand.Read(xCoordinates)
+	}
+	shuffleXCoordinatesUpTo(xCoordinates, xCoordinates, 256-packing)
+
+	// the stripes, the last one padded with zeros
+	N := (len(secret) + packing - 1) / packing
+	stripes, put := getScratch(packing * N)
+	defer put()
+	copy(stripes, secret)
+	for i := len(secret); i < len(stripes); i++ {
+		stripes[i] = 0
+	}
+
+	shares := make([][]byte, parts)
+	for i := range shares {
+		shares[i] = make([]byte, N+RampShareOverhead)
+		binary.LittleEndian.PutUint32(shares[i][N:], uint32(len(secret)))
+		shares[i][N+4] = byte(privacy)
+		shares[i][N+5] = byte(packing)
+		shares[i][N+6] = xCoordinates[i]
+	}
+
+	// the first `privacy` shares are random, together with the stripes
+	// they define the polynomials
+	knownXs := make([]byte, 0, threshold)
+	known := make([][]byte, 0, threshold)
+	for l := 0; l < packing; l++ {
+		knownXs = append(knownXs, rampSecretX(l))
+		known = append(known, stripes[l*N:(l+1)*N])
+	}
+	for i := 0; i < privacy; i++ {
+		var err error
+		if randomizer != nil {
+			_, err = randomizer.Read(shares[i][:N])
+		} else {
+			_, err = rand.Read(shares[i][:N])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate the random shares: %v", err)
+		}
+		knownXs = append(knownXs, xCoordinates[i])
+		known = append(known, shares[i][:N])
+	}
+
+	// the other shares are interpolated
+	for i := privacy; i < parts; i++ {
+		weights := lagrangeWeightsAt(knownXs, xCoordinates[i])
+		for k, y := range known {
+			gf.MulAddVector(weights[k], y, shares[i][:N])
+		}
+	}
+	return shares, nil
+}
+
+// CombineRamp reconstructs the secret from at least privacy+packing
+// shares of SplitRamp.
+func CombineRamp(parts [][]byte) ([]byte, error) {
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("no part is given")
+	}
+	partLen := len(parts[0])
+	if partLen <= RampShareOverhead {
+		return nil, fmt.Errorf("parts must be at least %d bytes", RampShareOverhead+1)
+	}
+	N := partLen - RampShareOverhead
+	trailer := parts[0][N : partLen-1]
+	secretLen := int(binary.LittleEndian.Uint32(trailer))
+	privacy, packing := int(trailer[4]), int(trailer[5])
+	if packing < 1 || privacy < 1 || secretLen == 0 || (secretLen+packing-1)/packing != N {
+		return nil, fmt.Errorf("invalid ramp share trailer")
+	}
+	threshold := privacy + packing
+	if len(parts) < threshold {
+		return nil, fmt.Errorf("expecting at least %d parts, but got %d", threshold, len(parts))
+	}
+
+	parts = parts[:threshold]
+	xSamples := make([]byte, threshold)
+	checkMap := map[byte]bool{}
+	for i, part := range parts {
+		if len(part) != partLen {
+			return nil, fmt.Errorf("all parts must be the same length")
+		}
+		if string(part[N:partLen-1]) != string(trailer) {
+			return nil, fmt.Errorf("all parts must be split from the same secret")
+		}
+		x := part[partLen-1]
+		if x == 0 || int(x) > 256-packing {
+			return nil, fmt.Errorf("invalid x coordinate %d", x)
+		}
+		if checkMap[x] {
+			return nil, fmt.Errorf("duplicate part detected")
+		}
+		checkMap[x] = true
+		xSamples[i] = x
+	}
+
+	stripes := make([]byte, packing*N)
+	for l := 0; l < packing; l++ {
+		weights := lagrangeWeightsAt(xSamples, rampSecretX(l))
+		stripe := stripes[l*N : (l+1)*N]
+		for i, part := range parts {
+			gf.MulAddVector(weights[i], part[:N], stripe)
+		}
+	}
+	return stripes[:secretLen], nil
+}
@@ -0,0 +1,66 @@
+package shamir
+
+import (
+	"fmt"
+	"github.com/fadhilkurnia/shamir/csprng"
+	gf "github.com/fadhilkurnia/shamir/galois"
+)
+
+// Refresh re-randomizes the shares without changing the secret: the shares
+// of a fresh random polynomial whose intercepts are zero are added to the
+// shares, each at its own x coordinate. threshold must be the threshold the
+// shares were split with. The refreshed shares can not be combined with the
+// old ones, so a share leaked before the refresh is useless afterwards.
+// When randomizer is nil, math/rand is used.
+func Refresh(shares [][]byte, threshold int, randomizer *csprng.CSPRNG) ([][]byte, error) {
+	if err := checkParts(shares); err != nil {
+		return nil, err
+	}
+	N := len(shares[0]) - 1
+	xCoordinates := make([]byte, len(shares))
+	for i, share := range shares {
+		xCoordinates[i] = share[N]
+	}
+
+	contributions, err := NewRefreshContribution(xCoordinates, N, threshold, randomizer)
+	if err != nil {
+		return nil, err
+	}
+	refreshed := make([][]byte, len(shares))
+	for i, share := range shares {
+		if refreshed[i], err = ApplyRefresh(share, contributions[i:i+1]); err != nil {
+			return nil, err
+		}
+	}
+	return refreshed, nil
+}
+
+// NewRefreshContribution is the part of a single holder in a distributed
+// refresh, where no one sees all the shares. Every holder generates the
+// shares of its own zero-intercept polynomial at the x coordinates of all
+// the holders, then sends the i-th one to the holder of xCoordinates[i].
+// Each holder applies the contributions it received with ApplyRefresh.
+func NewRefreshContribution(xCoordinates []byte, secretLen, threshold int, randomizer *csprng.CSPRNG) ([][]byte, error) {
+	return SplitAt(make([]byte, secretLen), xCoordinates, threshold, randomizer)
+}
+
+// ApplyRefresh adds the contributions received by a holder, one from
+// each holder, to its share. All the contributions must be for the
+// x coordinate of the share.
+func ApplyRefresh(share []byte, contributions [][]byte) ([]byte, error) {
+	if len(share) < 2 {
+		return nil, fmt.Errorf("the share must be at least two bytes")
+	}
+	N := len(share) - 1
+	refreshed := append([]byte(nil), share...)
+	for i, contribution := range contributions {
+		if len(contribution) != len(share) {
+			return nil, fmt.Errorf("contribution %d has %d bytes, but the share has %d bytes", i, len(contribution), len(share))
+		}
+		if contribution[N] != share[N] {
+			return nil, fmt.Errorf("contribution %d is for x coordinate %d, but the share is at %d", i, contribution[N], share[N])
+		}
+		gf.AddVector(contribution[:N], refreshed[:N])
+	}
+	return refreshed, nil
+}
@@ -0,0 +1,67 @@
+package shamir
+
+import (
+	"fmt"
+	"github.com/fadhilkurnia/shamir/csprng"
+)
+
+// ReshareSplit is run by each old holder taking part in resharing the
+// secret from (threshold, parts) to (newThreshold, new parts), without
+// reconstructing it. At least `threshold` old holders must take part. The
+// share is split into one sub-share for each x coordinate of the new
+// holders, newThreshold of which are required to reconstruct the share.
+// Each sub-share is {y1, y2, .., yN, new x, old x}, the i-th sub-share is
+// sent to the new holder of newXCoordinates[i], see ReshareCombine.
+// When randomizer is nil, math/rand is used.
+func ReshareSplit(share []byte, newXCoordinates []byte, newThreshold int, randomizer *csprng.CSPRNG) ([][]byte, error) {
+	if len(share) < 2 {
+		return nil, fmt.Errorf("the share must be at least two bytes")
+	}
+	N := len(share) - 1
+	subShares, err := SplitAt(share[:N], newXCoordinates, newThreshold, randomizer)
+	if err != nil {
+		return nil, err
+	}
+
+	// append the x coordinate of the old share to each sub-share
+	buff := make([]byte, len(subShares)*(N+2))
+	for i, subShare := range subShares {
+		out := buff[i*(N+2) : (i+1)*(N+2) : (i+1)*(N+2)]
+		copy(out, subShare)
+		out[N+1] = share[N]
+		subShares[i] = out
+	}
+	return subShares, nil
+}
+
+// ReshareCombine is run by each new holder on the sub-shares it received,
+// one from each old holder taking part in the resharing. Since the shares
+// of the sub-shares are linear, the new share is the Lagrange combination
+// at zero of the sub-shares, weighted by the x coordinates of the old shares.
+func ReshareCombine(subShares [][]byte) ([]byte, error) {
+	if err := checkParts(subShares); err != nil {
+		return nil, err
+	}
+	N := len(subShares[0]) - 2
+	if N < 1 {
+		return nil, fmt.Errorf("sub-shares must be at least three bytes")
+	}
+	oldXCoordinates := make([]byte, len(subShares))
+	for i, subShare := range subShares {
+		if subShare[N] != subShares[0][N] {
+			return nil, fmt.Errorf("all sub-shares must be for the same new x coordinate")
+		}
+		oldXCoordinates[i] = subShare[N+1]
+	}
+	if err := checkXCoordinates(oldXCoordinates); err != nil {
+		return nil, err
+	}
+
+	// the weights are read from the last byte, which is the old x coordinate
+	share := make([]byte, N+1)
+	if err := combiners.get(oldXCoordinates).combine(subShares, share[:N]); err != nil {
+		return nil, err
+	}
+	share[N] = subShares[0][N]
+	return share, nil
+}
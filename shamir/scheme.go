@@ -0,0 +1,53 @@
+package shamir
+
+import (
+	"context"
+	"github.com/fadhilkurnia/shamir/csprng"
+)
+
+// Scheme is shamir's secret-sharing as a secret-sharing scheme.
+type Scheme struct{}
+
+// Name returns the name of the scheme.
+func (Scheme) Name() string {
+	return "shamir"
+}
+
+// Split is the same as SplitWithRandomizer.
+func (Scheme) Split(secret []byte, parts, threshold int, randomizer *csprng.CSPRNG) ([][]byte, error) {
+	return SplitWithRandomizer(secret, parts, threshold, randomizer)
+}
+
+// SplitContext is the same as SplitWithRandomizerContext.
+func (Scheme) SplitContext(ctx context.Context, secret []byte, parts, threshold int, randomizer *csprng.CSPRNG) ([][]byte, error) {
+	return SplitWithRandomizerContext(ctx, secret, parts, threshold, randomizer)
+}
+
+// Combine is the same as the package-level Combine, except that
+// the shares for missing parts can be given as nil.
+func (Scheme) Combine(shares [][]byte) ([]byte, error) {
+	return Combine(presentShares(shares))
+}
+
+// CombineContext is the same as Combine, but ctx is checked
+// between stripes, see the package-level CombineContext.
+func (Scheme) CombineContext(ctx context.Context, shares [][]byte) ([]byte, error) {
+	return CombineContext(ctx, presentShares(shares))
+}
+
+// presentShares drops the shares of the missing parts.
+func presentShares(shares [][]byte) [][]byte {
+	parts := make([][]byte, 0, len(shares))
+	for _, share := range shares {
+		if share != nil {
+			parts = append(parts, share)
+		}
+	}
+	return parts
+}
+
+// ShareOverhead returns how many bytes each share is larger than
+// len(secret)/threshold, the smallest possible share size.
+func (Scheme) ShareOverhead(secretLen, parts, threshold int) int {
+	return secretLen + ShareOverhead - (secretLen+threshold-1)/threshold
+}
@@ -1,12 +1,11 @@
 package shamir
 
 import (
+	"context"
 	"fmt"
 	"github.com/fadhilkurnia/shamir/csprng"
 	"github.com/fadhilkurnia/shamir/utils"
-	"log"
 	"math/rand"
-	"sync"
 )
 
 const (
@@ -16,10 +15,26 @@ const (
 	ShareOverhead = 1
 )
 
+// bPool keeps the coefficient buffers, which are wiped when put back
+// since the coefficients and a single share reveal the secret.
 var bPool *utils.BytesBufferPool
 
 func init() {
-	bPool = utils.NewBytesBufferPool(0)
+	bPool = utils.NewSecureBytesBufferPool(0)
+}
+
+// getScratch returns a buffer of size bytes for secret-bearing
+// intermediates, drawn from the allocator set with utils.SetAllocator,
+// otherwise from bPool. put wipes the buffer and gives it back.
+func getScratch(size int) (b []byte, put func()) {
+	if a := utils.SecretAllocator(); a != nil {
+		s := a.Alloc(size)
+		return s.Bytes(), s.Destroy
+	}
+	bb := bPool.Get()
+	bb.Reset()
+	bb.Grow(size)
+	return bb.Bytes()[:size], func() { bPool.Put(bb) }
 }
 
 // Split takes an arbitrarily long secret and generates a `parts`
@@ -46,61 +61,99 @@ func Split(secret []byte, parts, threshold int) ([][]byte, error) {
 	}
 
 	// Generate random list of x coordinates
-	xCoordinates := rand.Perm(255)
-
-	// Allocate the output array, initialize the final byte
-	// of the output with the offset. The representation of each
-	// output is {y1, y2, .., yN, x}.
-	// part1: {y1, y2, .., yN, x}
-	// part2: {y1, y2, .., yN, x}
-	// ...
-	// partN: {y1, y2, .., yN, x}
-	out := make([][]byte, parts)
-	buff := make([]byte, (len(secret)+1)*parts)
-	for idx := range out {
-		s := (len(secret)+1)*idx
-		e := s + len(secret)+1
-		out[idx] = buff[s:e]
-		out[idx][len(secret)] = uint8(xCoordinates[idx]) + 1
+	perm := rand.Perm(255)
+	xCoordinates := make([]byte, parts)
+	for idx := range xCoordinates {
+		xCoordinates[idx] = uint8(perm[idx]) + 1
 	}
 
-	N := len(secret)
-	degree := threshold-1
+	return splitAt(secret, xCoordinates, threshold, nil)
+}
 
-	// get temporary buffers from pool
-	polBytesBuff := bPool.Get()
-	defer bPool.Put(polBytesBuff)
-	polBytesBuff.Reset()
-	polBytesBuff.Grow((degree+1)*N)
-	polBuff := polBytesBuff.Bytes()[0:(degree+1)*N]
+// SplitAt is similar with Split, but the shares are generated at the
+// given x coordinates instead of random ones, one share for each
+// x coordinate. The x coordinates must be unique and non-zero. When
+// randomizer is nil, math/rand is used to generate the polynomials.
+func SplitAt(secret []byte, xCoordinates []byte, threshold int, randomizer *csprng.CSPRNG) ([][]byte, error) {
+	// Sanity check the input
+	if len(xCoordinates) < threshold {
+		return nil, fmt.Errorf("parts cannot be less than threshold")
+	}
+	if len(xCoordinates) > 255 {
+		return nil, fmt.Errorf("parts cannot exceed 255")
+	}
+	if threshold < 2 {
+		return nil, fmt.Errorf("threshold must be at least 2")
+	}
+	if threshold > 255 {
+		return nil, fmt.Errorf("threshold cannot exceed 255")
+	}
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("cannot split an empty secret")
+	}
+	if err := checkXCoordinates(xCoordinates); err != nil {
+		return nil, err
+	}
 
-	// Construct a random polynomial for N bytes of the secret.
-	// Because we are using a field of size 256, we can only represent
-	// a single byte as the intercept of the polynomial, so we must
-	// use a new polynomial for each byte.
-	// polynomials is a matrix with (N x degree+1) dimension
-	polynomials, err := makePolynomialsWithBuff(secret, degree, polBuff)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate polynomial: %v", err)
+	return splitAt(secret, xCoordinates, threshold, randomizer)
+}
+
+// checkXCoordinates ensures the x coordinates can be used to evaluate
+// the polynomials, the x coordinates must be unique and can not be
+// zero since f(0) is the secret.
+func checkXCoordinates(xCoordinates []byte) error {
+	checkMap := map[byte]bool{}
+	for _, x := range xCoordinates {
+		if x == 0 {
+			return fmt.Errorf("x coordinate cannot be zero")
+		}
+		if checkMap[x] {
+			return fmt.Errorf("duplicate x coordinate detected")
+		}
+		checkMap[x] = true
 	}
+	return nil
+}
+
+// splitAt generates the shares at the given x coordinates,
+// assuming the inputs were already checked.
+func splitAt(secret []byte, xCoordinates []byte, threshold int, randomizer *csprng.CSPRNG) ([][]byte, error) {
+	return splitWithPowers(secret, xCoordinates, computePowers(xCoordinates, threshold-1), randomizer)
+}
 
-	// prepare temporary buffer for the transpose of the polynomials
-	polTransposeBytesBuff := bPool.Get()
-	defer bPool.Put(polTransposeBytesBuff)
-	polTransposeBytesBuff.Reset()
-	polTransposeBytesBuff.Grow(len(polynomials))
+// randomXCoordinates returns `parts` unique random non-zero x coordinates.
+// The randomizer is used when it is not nil, otherwise math/rand is used.
+func randomXCoordinates(parts int, randomizer *csprng.CSPRNG) []byte {
+	xCoordinates := make([]byte, parts)
+	if randomizer != nil {
+		_, _ = randomizer.Read(xCoordinates)
+	} else {
+		_, _ = rand.Read(xCoordinates)
+	}
+	shuffleXCoordinates(xCoordinates, xCoordinates)
+	return xCoordinates
+}
 
-	// transposing polynomials for polynomial evaluation
-	coefficientBuff := polTransposeBytesBuff.Bytes()[0:len(polynomials)]
-	transposeMatrixBuffer(coefficientBuff, polynomials, degree+1)
+// shuffleXCoordinates fills xCoordinates with a partial shuffle of all the
+// non-zero x coordinates, using one random byte of shuffler for each x.
+// The shuffler can be xCoordinates itself.
+func shuffleXCoordinates(xCoordinates, shuffler []byte) {
+	shuffleXCoordinatesUpTo(xCoordinates, shuffler, 255)
+}
 
-	// evaluating the polynomials at the secret points x
-	for i := 0; i < parts; i++ {
-		evaluatePolynomialsAtWithCoefficientsBuffer(coefficientBuff, N, uint8(xCoordinates[i])+1, out[i][0:N])
+// shuffleXCoordinatesUpTo is the same with shuffleXCoordinates, but the
+// x coordinates are in [1, maxX].
+func shuffleXCoordinatesUpTo(xCoordinates, shuffler []byte, maxX int) {
+	var all [255]byte
+	candidates := all[:maxX]
+	for i := range candidates {
+		candidates[i] = byte(i + 1)
 	}
-
-	// Return the encoded secrets
-	return out, nil
+	for j := range xCoordinates {
+		k := j + int(shuffler[j])%(len(candidates)-j)
+		candidates[j], candidates[k] = candidates[k], candidates[j]
+	}
+	copy(xCoordinates, candidates[:len(xCoordinates)])
 }
 
 // SplitWithRandomizerOld will be deprecated soon
@@ -150,7 +203,9 @@ func SplitWithRandomizerOld(secret []byte, parts, threshold int, randomizer *csp
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate polynomial: %v", err)
 	}
+	defer utils.WipeMatrix(polynomials)
 	coefficients := transpose(polynomials)
+	defer utils.WipeMatrix(coefficients)
 	for i := 0; i < parts; i++ {
 		evaluatePolynomialsAt(coefficients, uint8(xCoordinates[i])+1, out[i])
 	}
@@ -178,61 +233,9 @@ func SplitWithRandomizer(secret []byte, parts, threshold int, randomizer *csprng
 	}
 
 	// Generate random list of x coordinates
-	xCoordinates := randomizer.GetUniqueBytes(byte(parts))
-
-	// Allocate the output array, initialize the final byte
-	// of the output with the offset. The representation of each
-	// output is {y1, y2, .., yN, x}.
-	// part1: {y1, y2, .., yN, x}
-	// part2: {y1, y2, .., yN, x}
-	// ...
-	// partN: {y1, y2, .., yN, x}
-	out := make([][]byte, parts)
-	buff := make([]byte, (len(secret)+1)*parts)
-	for idx := range out {
-		s := (len(secret)+1)*idx
-		e := s + len(secret)+1
-		out[idx] = buff[s:e]
-		out[idx][len(secret)] = uint8(xCoordinates[idx]) + 1
-	}
-
-	N := len(secret)
-	degree := threshold-1
-
-	// get temporary buffers from pool
-	polBytesBuff := bPool.Get()
-	defer bPool.Put(polBytesBuff)
-	polBytesBuff.Reset()
-	polBytesBuff.Grow((degree+1)*N)
-	polBuff := polBytesBuff.Bytes()[0:(degree+1)*N]
+	xCoordinates := randomXCoordinates(parts, randomizer)
 
-	// Construct a random polynomial for N bytes of the secret.
-	// Because we are using a field of size 256, we can only represent
-	// a single byte as the intercept of the polynomial, so we must
-	// use a new polynomial for each byte.
-	// polynomials is a matrix with (N x degree+1) dimension
-	polynomials, err := makePolynomialsWithBuffAndRandomizer(secret, degree, polBuff, randomizer)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate polynomial: %v", err)
-	}
-
-	// prepare temporary buffer for the transpose of the polynomials
-	polTransposeBytesBuff := bPool.Get()
-	defer bPool.Put(polTransposeBytesBuff)
-	polTransposeBytesBuff.Reset()
-	polTransposeBytesBuff.Grow(len(polynomials))
-
-	// transposing polynomials for polynomial evaluation
-	coefficientBuff := polTransposeBytesBuff.Bytes()[0:len(polynomials)]
-	transposeMatrixBuffer(coefficientBuff, polynomials, degree+1)
-
-	// evaluating the polynomials at the secret points x
-	for i := 0; i < parts; i++ {
-		evaluatePolynomialsAtWithCoefficientsBuffer(coefficientBuff, N, uint8(xCoordinates[i])+1, out[i][0:N])
-	}
-
-	// Return the encoded secrets
-	return out, nil
+	return splitAt(secret, xCoordinates, threshold, randomizer)
 }
 
 func SplitGeneric(secret []byte, parts, threshold int) ([][]byte, error) {
@@ -278,7 +281,9 @@ func SplitGeneric(secret []byte, parts, threshold int) ([][]byte, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate polynomial: %v", err)
 	}
+	defer utils.WipeMatrix(polynomials)
 	coefficients := transpose(polynomials)
+	defer utils.WipeMatrix(coefficients)
 	for i := 0; i < parts; i++ {
 		genericEvaluatePolynomialsAt(coefficients, uint8(xCoordinates[i])+1, out[i])
 	}
@@ -287,202 +292,167 @@ func SplitGeneric(secret []byte, parts, threshold int) ([][]byte, error) {
 	return out, nil
 }
 
+// SplitP is the parallel version of Split, for large secrets,
+// see SplitWithRandomizerP.
 func SplitP(secret []byte, parts, threshold int) ([][]byte, error) {
-	// Sanity check the input
-	if parts < threshold {
-		return nil, fmt.Errorf("parts cannot be less than threshold")
-	}
-	if parts > 255 {
-		return nil, fmt.Errorf("parts cannot exceed 255")
-	}
-	if threshold < 2 {
-		return nil, fmt.Errorf("threshold must be at least 2")
-	}
-	if threshold > 255 {
-		return nil, fmt.Errorf("threshold cannot exceed 255")
-	}
-	if len(secret) == 0 {
-		return nil, fmt.Errorf("cannot split an empty secret")
-	}
-
-	// Generate random list of x coordinates
-	xCoordinates := rand.Perm(255)
+	return SplitWithRandomizerP(secret, parts, threshold, nil)
+}
 
-	// Allocate the output array, initialize the final byte
-	// of the output with the offset. The representation of each
-	// output is {y1, y2, .., yN, x}.
-	out := make([][]byte, parts)
-	for idx := range out {
-		out[idx] = make([]byte, len(secret)+1)
-		out[idx][len(secret)] = uint8(xCoordinates[idx]) + 1
+// Combine is used to reverse a Split and reconstruct a secret
+// once a `threshold` number of parts are available. The Lagrange
+// basis of recently used sets of x coordinates is cached, use a
+// Combiner to keep the basis of a fixed set of x coordinates.
+func Combine(parts [][]byte) ([]byte, error) {
+	if err := checkParts(parts); err != nil {
+		return nil, err
 	}
+	firstPartLen := len(parts[0])
 
-	// Construct a random polynomial for each byte of the secret.
-	// Because we are using a field of size 256, we can only represent
-	// a single byte as the intercept of the polynomial, so we must
-	// use a new polynomial for each byte.
-	idx := uint32(0)
-	secretLen := uint32(len(secret))
-	var wg sync.WaitGroup
-	for idx < secretLen {
-
-		endIdx := idx + 1024
-		if endIdx > secretLen {
-			endIdx = secretLen
-		}
-
-		wg.Add(1)
-		go func(startIdx, endIdx uint32, values []byte) {
-			defer wg.Done()
-
-			crtIdx := startIdx
-			for _, val := range values {
-				p, err := makePolynomial(val, uint8(threshold-1))
-				if err != nil {
-					log.Fatalf("failed to generate polynomial: %s", err.Error())
-				}
+	// Get the combiner of the x value of the samples
+	xSamples := make([]uint8, len(parts))
+	for i, part := range parts {
+		xSamples[i] = part[firstPartLen-1]
+	}
+	c := combiners.get(xSamples)
 
-				// Generate a `parts` number of (x,y) pairs
-				// We cheat by encoding the x value once as the final index,
-				// so that it only needs to be stored once.
-				for i := 0; i < parts; i++ {
-					x := uint8(xCoordinates[i]) + 1
-					y := p.evaluate(x)
-					out[i][crtIdx] = y
-				}
-				crtIdx += 1
-			}
+	// Reconstruct all the bytes at once
+	secret := make([]byte, firstPartLen-1)
+	if err := c.combine(parts, secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
 
-		}(idx, endIdx, secret[idx:endIdx])
-		idx = endIdx
+// CombineSecret is similar with Combine, but the secret is reconstructed
+// into a SecretBuffer, which the caller wipes with Destroy once done. The
+// SecretBuffer comes from the allocator set with utils.SetAllocator.
+func CombineSecret(parts [][]byte) (*utils.SecretBuffer, error) {
+	if err := checkParts(parts); err != nil {
+		return nil, err
 	}
+	xSamples := make([]uint8, len(parts))
+	for i, part := range parts {
+		xSamples[i] = part[len(part)-1]
+	}
+	c := combiners.get(xSamples)
 
-	wg.Wait()
+	secret := utils.NewSecretBuffer(len(parts[0]) - 1)
+	if err := c.combine(parts, secret.Bytes()); err != nil {
+		secret.Destroy()
+		return nil, err
+	}
+	return secret, nil
+}
 
-	// Return the encoded secrets
-	return out, nil
+// Regenerate regenerates more secret shares given enough secret-shares
+// to reconstruct the secret polynomial (secret value). Regenerate is
+// similar with Combine, but we keep the original polynomial instead
+// of regenerating another secret polynomial.
+func Regenerate(parts [][]byte, numNewShares int) ([][]byte, error) {
+	return RegenerateContext(context.Background(), parts, numNewShares)
 }
 
-// Combine is used to reverse a Split and reconstruct a secret
-// once a `threshold` number of parts are available.
-func Combine(parts [][]byte) ([]byte, error) {
-	// Verify enough parts provided
-	if len(parts) < 2 {
-		return nil, fmt.Errorf("less than two parts cannot be used to reconstruct the secret")
+// RegenerateContext is similar with Regenerate, but ctx is checked between
+// stripes of the shares, ctx.Err() is returned once ctx is done.
+func RegenerateContext(ctx context.Context, parts [][]byte, numNewShares int) ([][]byte, error) {
+	if err := checkParts(parts); err != nil {
+		return nil, err
+	}
+	if numNewShares+len(parts) > 255 {
+		return nil, fmt.Errorf("parts cannot exceed 255")
 	}
 
-	// Verify the parts are all the same length
-	firstPartLen := len(parts[0])
-	if firstPartLen < 2 {
-		return nil, fmt.Errorf("parts must be at least two bytes")
+	// generate new random x, ensure no duplicate x generated
+	// and x is never zero as f(0) is the secret
+	checkMap := map[byte]bool{0: true}
+	for _, part := range parts {
+		checkMap[part[len(part)-1]] = true
 	}
-	for i := 1; i < len(parts); i++ {
-		if len(parts[i]) != firstPartLen {
-			return nil, fmt.Errorf("all parts must be the same length")
+	newXs := make([]byte, numNewShares)
+	for i := range newXs {
+		nx := byte(rand.Uint32())
+		for checkMap[nx] {
+			nx = byte(rand.Uint32())
 		}
+		checkMap[nx] = true
+		newXs[i] = nx
 	}
 
-	// Create a buffer to store the reconstructed secret
-	secret := make([]byte, firstPartLen-1)
+	return regenerateAt(ctx, parts, newXs)
+}
 
-	// Buffer to store the samples
-	x_samples := make([]uint8, len(parts))
-	y_samples := make([]uint8, len(parts))
+// RegenerateAt is similar with Regenerate, but the new shares are
+// generated at the given x coordinates. The x coordinates must be non-zero,
+// but can overlap with the x coordinates of the given parts, which is
+// useful to recover a lost share.
+func RegenerateAt(parts [][]byte, xCoordinates []byte) ([][]byte, error) {
+	if err := checkParts(parts); err != nil {
+		return nil, err
+	}
+	if err := checkXCoordinates(xCoordinates); err != nil {
+		return nil, err
+	}
+	return regenerateAt(context.Background(), parts, xCoordinates)
+}
 
-	// Set the x value for each sample and ensure no x_sample values are the same,
-	// otherwise div() can be unhappy
-	checkMap := map[byte]bool{}
+// regenerateAt assumes the parts and the x coordinates are already checked.
+func regenerateAt(ctx context.Context, parts [][]byte, xCoordinates []byte) ([][]byte, error) {
+	N := len(parts[0]) - 1
+	xSamples := make([]uint8, len(parts))
 	for i, part := range parts {
-		samp := part[firstPartLen-1]
-		if exists := checkMap[samp]; exists {
-			return nil, fmt.Errorf("duplicate part detected")
-		}
-		checkMap[samp] = true
-		x_samples[i] = samp
+		xSamples[i] = part[N]
+	}
+	weights := make([][]byte, len(xCoordinates))
+	for k, x := range xCoordinates {
+		weights[k] = lagrangeWeightsAt(xSamples, x)
 	}
 
-	// Reconstruct each byte
-	for idx := range secret {
-		// Set the y value for each sample
-		for i, part := range parts {
-			y_samples[i] = part[idx]
+	// each new share is the weighted sum of the parts
+	out := newShares(N, xCoordinates)
+	err := runStripes(N, 1, func(_, from, to int) error {
+		if err := ctx.Err(); err != nil {
+			return err
 		}
-
-		// Interpolate the polynomial and compute the value at 0
-		val := interpolatePolynomial(x_samples, y_samples, 0)
-
-		// Evaluate the 0th value to get the intercept
-		secret[idx] = val
+		for k := range xCoordinates {
+			combineStripe(parts, weights[k], out[k], from, to)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	return secret, nil
+	return out, nil
 }
 
-// Regenerate regenerates more secret shares given enough secret-shares
-// to reconstruct the secret polynomial (secret value). Regenerate is
-// similar with Combine, but we keep the original polynomial instead
-// of regenerating another secret polynomial.
-func Regenerate(parts [][]byte, numNewShares int) ([][]byte, error) {
+// checkParts verifies the given parts can be used to interpolate the
+// secret polynomials: there are at least two parts, all the parts have the
+// same length, and no duplicate x coordinate.
+func checkParts(parts [][]byte) error {
 	// Verify enough parts provided
 	if len(parts) < 2 {
-		return nil, fmt.Errorf("less than two parts cannot be used to reconstruct the secret")
+		return fmt.Errorf("less than two parts cannot be used to reconstruct the secret")
 	}
 
 	// Verify the parts are all the same length
 	firstPartLen := len(parts[0])
 	if firstPartLen < 2 {
-		return nil, fmt.Errorf("parts must be at least two bytes")
+		return fmt.Errorf("parts must be at least two bytes")
 	}
 	for i := 1; i < len(parts); i++ {
 		if len(parts[i]) != firstPartLen {
-			return nil, fmt.Errorf("all parts must be the same length")
+			return fmt.Errorf("all parts must be the same length")
 		}
 	}
 
-	// TODO: make a more efficient version of this by allocating less memory
-	// Create buffer to store the new shares
-	newShares := make([][]byte, numNewShares)
-	x_samples := make([]uint8, len(parts))
-	y_samples := make([]uint8, len(parts))
-	newXs := make([]byte, numNewShares)
-
-	// Set the x value for each sample and ensure no x_sample values are the same,
+	// Ensure no x_sample values are the same,
 	// otherwise div() can be unhappy
 	checkMap := map[byte]bool{}
 	for _, part := range parts {
 		samp := part[firstPartLen-1]
 		if exists := checkMap[samp]; exists {
-			return nil, fmt.Errorf("duplicate part detected")
+			return fmt.Errorf("duplicate part detected")
 		}
 		checkMap[samp] = true
 	}
-
-	// generate new random x
-	if _, err := rand.Read(newXs); err != nil {
-		return nil, fmt.Errorf("failed to generate new random x: %v", err)
-	}
-	// ensure no duplicate x generated
-	for _, nx := range newXs {
-		exists := checkMap[nx]
-		for exists {
-			nx = byte(rand.Uint32())
-			exists = checkMap[nx]
-		}
-		checkMap[nx] = true
-	}
-
-	for i, share := range parts {
-		x_samples[i] = share[firstPartLen-1]
-	}
-	for k:=0; k < numNewShares; k++ {
-		newShares[k] = make([]byte, firstPartLen) // TODO: make this more efficient by allocating with buffer pool
-		newShares[k][firstPartLen-1] = newXs[k]
-		for j := 0; j < firstPartLen-1; j++ {
-			for i, share := range parts {
-				y_samples[i] = share[j]
-			}
-			newShares[k][j] = interpolatePolynomial(x_samples, y_samples, newXs[k])
-		}
-	}
-
-	return newShares, nil
+	return nil
 }
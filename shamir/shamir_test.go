@@ -1,7 +1,11 @@
 package shamir
 
 import (
+	"bytes"
+	"context"
 	"github.com/fadhilkurnia/shamir/csprng"
+	gf "github.com/fadhilkurnia/shamir/galois"
+	"github.com/fadhilkurnia/shamir/utils"
 	hcShamir "github.com/hashicorp/vault/shamir"
 	"math/rand"
 	"reflect"
@@ -364,3 +368,812 @@ func TestRegenerateShares(t *testing.T) {
 		t.Errorf("%v vs %v", secretMsg, combinedShares)
 	}
 }
+
+func TestSplitAtRegenerateAt(t *testing.T) {
+	secretMsg := []byte("The quick brown fox jumps over the lazy dog")
+	xCoordinates := []byte{1, 2, 3, 4, 5}
+
+	shares, err := SplitAt(secretMsg, xCoordinates, 3, csprng.NewCSPRNG())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, share := range shares {
+		if share[len(share)-1] != xCoordinates[i] {
+			t.Errorf("expecting share %d at x=%d, but got x=%d", i, xCoordinates[i], share[len(share)-1])
+		}
+	}
+
+	// recover the lost share at x=2 from other shares
+	recovered, err := RegenerateAt([][]byte{shares[0], shares[2], shares[4]}, xCoordinates[1:2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(shares[1], recovered[0]) {
+		t.Errorf("The regenerated share is different. Expected: '%v', but got '%v'.\n", shares[1], recovered[0])
+	}
+
+	if _, err := SplitAt(secretMsg, []byte{1, 0, 3}, 2, nil); err == nil {
+		t.Errorf("expecting an error for zero x coordinate")
+	}
+	if _, err := SplitAt(secretMsg, []byte{1, 3, 3}, 2, nil); err == nil {
+		t.Errorf("expecting an error for duplicate x coordinates")
+	}
+}
+
+func TestSplitBatch(t *testing.T) {
+	secrets := make([][]byte, 100)
+	for i := range secrets {
+		secrets[i] = make([]byte, 1+rand.Intn(100))
+		rand.Read(secrets[i])
+	}
+	xCoordinates := []byte{7, 1, 200, 33, 255}
+
+	for _, split := range []func() ([][][]byte, error){
+		func() ([][][]byte, error) { return SplitBatch(secrets, 5, 3) },
+		func() ([][][]byte, error) { return SplitBatchWithRandomizer(secrets, 5, 3, csprng.NewCSPRNG()) },
+		func() ([][][]byte, error) { return SplitBatchAt(secrets, xCoordinates, 3, csprng.NewCSPRNG()) },
+	} {
+		shares, err := split()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, secret := range secrets {
+			if len(shares[i]) != 5 || len(shares[i][0]) != len(secret)+1 {
+				t.Fatalf("expecting 5 shares of %d bytes, but got %d shares of %d bytes", len(secret)+1, len(shares[i]), len(shares[i][0]))
+			}
+			combinedShares, err := Combine(shares[i][2:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(secret, combinedShares) {
+				t.Errorf("The combined secret is different. Expected: '%v', but got '%v'.\n", secret, combinedShares)
+			}
+		}
+	}
+
+	shares, _ := SplitBatchAt(secrets, xCoordinates, 3, nil)
+	for i := range secrets {
+		for j, share := range shares[i] {
+			if share[len(share)-1] != xCoordinates[j] {
+				t.Errorf("expecting share %d at x=%d, but got x=%d", j, xCoordinates[j], share[len(share)-1])
+			}
+		}
+	}
+
+	if _, err := SplitBatch([][]byte{secrets[0], {}}, 5, 3); err == nil {
+		t.Errorf("expecting an error for an empty secret in the batch")
+	}
+}
+
+func TestCombiner(t *testing.T) {
+	secrets := make([][]byte, 20)
+	for i := range secrets {
+		secrets[i] = make([]byte, 1+rand.Intn(200))
+		rand.Read(secrets[i])
+	}
+	shares, err := SplitBatchAt(secrets, []byte{1, 2, 3, 4, 5}, 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewCombiner([]byte{5, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch := make([][][]byte, len(secrets))
+	for i := range secrets {
+		batch[i] = [][]byte{shares[i][1], shares[i][4], shares[i][2]}
+		combinedShares, err := c.Combine(batch[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(secrets[i], combinedShares) {
+			t.Errorf("The combined secret is different. Expected: '%v', but got '%v'.\n", secrets[i], combinedShares)
+		}
+	}
+	combinedSecrets, err := c.CombineBatch(batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(secrets, combinedSecrets) {
+		t.Errorf("The combined secrets are different. Expected: '%v', but got '%v'.\n", secrets, combinedSecrets)
+	}
+
+	if _, err := c.Combine([][]byte{shares[0][0], shares[0][1], shares[0][2]}); err == nil {
+		t.Errorf("expecting an error for a part at an unexpected x coordinate")
+	}
+	if _, err := NewCombiner([]byte{1, 1, 2}); err == nil {
+		t.Errorf("expecting an error for duplicate x coordinates")
+	}
+}
+
+func TestCombineCache(t *testing.T) {
+	secretMsg := []byte("The quick brown fox jumps over the lazy dog")
+	shares, _ := Split(secretMsg, 20, 2)
+
+	// more sets of x coordinates than the cache can hold
+	for i := 0; i < 2*combinerCacheSize; i++ {
+		a, b := i%20, (i+1+i/20)%20
+		combinedShares, err := Combine([][]byte{shares[a], shares[b]})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(secretMsg, combinedShares) {
+			t.Errorf("The combined secret is different. Expected: '%v', but got '%v'.\n", string(secretMsg), string(combinedShares))
+		}
+	}
+	if n := combiners.order.Len(); n > combinerCacheSize {
+		t.Errorf("expecting at most %d cached combiners, but got %d", combinerCacheSize, n)
+	}
+}
+
+func TestSplitter(t *testing.T) {
+	for _, size := range []int{1, 50, 1_000, 100_000} {
+		secretMsg := make([]byte, size)
+		rand.Read(secretMsg)
+		for _, threshold := range []int{2, 3, 20} {
+			s, err := NewSplitter(20, threshold)
+			if err != nil {
+				t.Fatal(err)
+			}
+			shares, err := s.SplitWithRandomizer(secretMsg, csprng.NewCSPRNG())
+			if err != nil {
+				t.Fatal(err)
+			}
+			combinedShares, err := Combine(shares[20-threshold:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(secretMsg, combinedShares) {
+				t.Errorf("The combined secret is different for %d bytes secret and threshold %d.\n", size, threshold)
+			}
+		}
+	}
+
+	s, err := NewSplitter(3, 2, WithXCoordinates([]byte{9, 8, 7}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(s.XCoordinates(), []byte{9, 8, 7}) {
+		t.Errorf("expecting x coordinates %v, but got %v", []byte{9, 8, 7}, s.XCoordinates())
+	}
+	if _, err := NewSplitter(4, 2, WithXCoordinates([]byte{9, 8, 7})); err == nil {
+		t.Errorf("expecting an error when the number of x coordinates is not the number of parts")
+	}
+	if _, err := NewSplitter(3, 2, WithXCoordinates([]byte{9, 0, 7})); err == nil {
+		t.Errorf("expecting an error for zero x coordinate")
+	}
+}
+
+func TestSplitterConcurrent(t *testing.T) {
+	secretMsg := []byte("The quick brown fox jumps over the lazy dog")
+	s, _ := NewSplitter(5, 3)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := csprng.NewCSPRNG()
+			for j := 0; j < 1_000; j++ {
+				shares, err := s.SplitWithRandomizer(secretMsg, r)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				combinedShares, _ := Combine(shares[1:4])
+				if !reflect.DeepEqual(secretMsg, combinedShares) {
+					t.Errorf("The combined secret is different. Expected: '%v', but got '%v'.\n", string(secretMsg), string(combinedShares))
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestSplitWithRandomizerXCoordinates(t *testing.T) {
+	r := csprng.NewCSPRNG()
+	for i := 0; i < 10_000; i++ {
+		shares, _ := SplitWithRandomizer([]byte{42}, 5, 2, r)
+		for _, share := range shares {
+			if share[1] == 0 {
+				t.Fatalf("a share is generated at x=0")
+			}
+		}
+	}
+}
+
+func TestSplitCombineP(t *testing.T) {
+	secretMsg := make([]byte, 5*stripeSize+123)
+	rand.Read(secretMsg)
+
+	for _, split := range []func() ([][]byte, error){
+		func() ([][]byte, error) { return SplitP(secretMsg, 5, 3) },
+		func() ([][]byte, error) { return SplitWithRandomizerP(secretMsg, 5, 3, csprng.NewCSPRNG()) },
+	} {
+		shares, err := split()
+		if err != nil {
+			t.Fatal(err)
+		}
+		combinedShares, err := CombineP(shares[1:4])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(secretMsg, combinedShares) {
+			t.Errorf("The combined secret is different.\n")
+		}
+		combinedShares, err = Combine(shares[:3])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(secretMsg, combinedShares) {
+			t.Errorf("The combined secret is different.\n")
+		}
+	}
+
+	// the stripes must not share the same random coefficients
+	s, _ := NewSplitter(2, 2)
+	zeros := make([]byte, 2*stripeSize)
+	shares, err := s.SplitP(zeros)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reflect.DeepEqual(shares[0][:stripeSize], shares[0][stripeSize:2*stripeSize]) {
+		t.Errorf("the stripes are generated with the same randomness")
+	}
+
+	if _, err := SplitP(nil, 5, 3); err == nil {
+		t.Errorf("expecting an error for an empty secret")
+	}
+}
+
+func TestSplitCombineContext(t *testing.T) {
+	secretMsg := make([]byte, 3*stripeSize+123)
+	rand.Read(secretMsg)
+
+	shares, err := SplitContext(context.Background(), secretMsg, 5, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	combinedShares, err := CombineContext(context.Background(), shares[1:4])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(secretMsg, combinedShares) {
+		t.Errorf("The combined secret is different.\n")
+	}
+	newShares, err := RegenerateContext(context.Background(), shares[:3], 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	combinedShares, err = Combine([][]byte{shares[4], newShares[0], newShares[1]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(secretMsg, combinedShares) {
+		t.Errorf("The combined secret is different.\n")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := SplitContext(ctx, secretMsg, 5, 3); err != context.Canceled {
+		t.Errorf("expecting context.Canceled when splitting, but got %v", err)
+	}
+	if _, err := CombineContext(ctx, shares[1:4]); err != context.Canceled {
+		t.Errorf("expecting context.Canceled when combining, but got %v", err)
+	}
+	if _, err := RegenerateContext(ctx, shares[:3], 2); err != context.Canceled {
+		t.Errorf("expecting context.Canceled when regenerating, but got %v", err)
+	}
+	c, _ := NewCombiner([]byte{shares[0][len(secretMsg)], shares[1][len(secretMsg)], shares[2][len(secretMsg)]})
+	if _, err := c.CombineContext(ctx, shares[:3]); err != context.Canceled {
+		t.Errorf("expecting context.Canceled when combining with a Combiner, but got %v", err)
+	}
+}
+
+func TestPoolsWiped(t *testing.T) {
+	secretMsg := make([]byte, 1000)
+	rand.Read(secretMsg)
+
+	if _, err := Split(secretMsg, 5, 3); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SplitBatch([][]byte{secretMsg, secretMsg[:10]}, 5, 3); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SplitWithRandomizerOld(secretMsg, 5, 3, csprng.NewCSPRNG()); err != nil {
+		t.Fatal(err)
+	}
+
+	// the buffers used to split must not keep the coefficients
+	for _, pool := range []*utils.BytesBufferPool{bPool, polynomialBufferPool} {
+		bb := pool.Get()
+		for _, v := range bb.Bytes()[:bb.Cap()] {
+			if v != 0 {
+				t.Fatalf("the pool leaks the previous contents of a buffer")
+			}
+		}
+		pool.Put(bb)
+	}
+}
+
+func TestCombineSecret(t *testing.T) {
+	secretMsg := []byte("The quick brown fox jumps over the lazy dog")
+	shares, err := Split(secretMsg, 5, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, err := CombineSecret(shares[:3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(secretMsg, secret.Bytes()) {
+		t.Errorf("The combined secret is different. Expected: '%v', but got '%v'.\n", string(secretMsg), string(secret.Bytes()))
+	}
+	b := secret.Bytes()
+	secret.Destroy()
+	if !reflect.DeepEqual(b, make([]byte, len(secretMsg))) {
+		t.Errorf("the combined secret is not wiped after Destroy: %v", b)
+	}
+}
+
+func TestSplitCombineLocked(t *testing.T) {
+	a := utils.NewLockedAllocator()
+	defer a.Close()
+	utils.SetAllocator(a)
+	defer utils.SetAllocator(nil)
+
+	secretMsg := make([]byte, 2*splitBlockSize+123)
+	rand.Read(secretMsg)
+	shares, err := Split(secretMsg, 5, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	batchShares, err := SplitBatch([][]byte{secretMsg[:10], secretMsg}, 5, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, parts := range [][][]byte{shares[2:], batchShares[1][:3]} {
+		secret, err := CombineSecret(parts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(secretMsg, secret.Bytes()) {
+			t.Errorf("The combined secret is different.\n")
+		}
+		if a.Fallbacks() == 0 && !secret.Locked() {
+			t.Errorf("the combined secret is not in locked memory")
+		}
+		secret.Destroy()
+	}
+}
+
+func TestSplitCombineConstantTime(t *testing.T) {
+	gf.SetConstantTime(true)
+	defer gf.SetConstantTime(false)
+
+	secretMsg := make([]byte, 1000)
+	rand.Read(secretMsg)
+	shares, err := Split(secretMsg, 5, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newShares, err := Regenerate(shares[:3], 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, parts := range [][][]byte{shares[2:], {shares[0], newShares[0], newShares[1]}} {
+		combinedShares, err := Combine(parts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(secretMsg, combinedShares) {
+			t.Errorf("The combined secret is different.\n")
+		}
+	}
+	// the line through (1, 3) and (2, 5) is at 3*2/3 + 5*1/3 for x=0
+	expected := gf.GalMultiplyCT(3, gf.GalDivideCT(2, 3)) ^ gf.GalMultiplyCT(5, gf.GalDivideCT(1, 3))
+	if s := interpolatePolynomial([]byte{1, 2}, []byte{3, 5}, 0); s != expected {
+		t.Errorf("expecting the interpolated value to be %d, but got %d", expected, s)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	secretMsg := []byte("The quick brown fox jumps over the lazy dog")
+	shares, err := Split(secretMsg, 5, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refreshed, err := Refresh(shares, 3, csprng.NewCSPRNG())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range shares {
+		if reflect.DeepEqual(shares[i], refreshed[i]) {
+			t.Errorf("share %d is not refreshed", i)
+		}
+	}
+	combinedShares, err := Combine(refreshed[2:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(secretMsg, combinedShares) {
+		t.Errorf("The combined secret is different. Expected: '%v', but got '%v'.\n", string(secretMsg), string(combinedShares))
+	}
+
+	// the old and the refreshed shares are incompatible
+	combinedShares, err = Combine([][]byte{shares[0], shares[1], refreshed[2]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reflect.DeepEqual(secretMsg, combinedShares) {
+		t.Errorf("the secret is combined from old and refreshed shares")
+	}
+}
+
+func TestDistributedRefresh(t *testing.T) {
+	secretMsg := []byte("The quick brown fox jumps over the lazy dog")
+	shares, err := Split(secretMsg, 4, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xCoordinates := make([]byte, len(shares))
+	for i, share := range shares {
+		xCoordinates[i] = share[len(secretMsg)]
+	}
+
+	// received[j] are the contributions sent to holder j
+	received := make([][][]byte, len(shares))
+	for range shares {
+		contributions, err := NewRefreshContribution(xCoordinates, len(secretMsg), 3, csprng.NewCSPRNG())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j := range contributions {
+			received[j] = append(received[j], contributions[j])
+		}
+	}
+	refreshed := make([][]byte, len(shares))
+	for j := range shares {
+		if refreshed[j], err = ApplyRefresh(shares[j], received[j]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	combinedShares, err := Combine(refreshed[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(secretMsg, combinedShares) {
+		t.Errorf("The combined secret is different. Expected: '%v', but got '%v'.\n", string(secretMsg), string(combinedShares))
+	}
+
+	if _, err := ApplyRefresh(shares[0], received[1]); err == nil {
+		t.Errorf("expecting an error when applying the contributions for another holder")
+	}
+}
+
+func TestReshare(t *testing.T) {
+	secretMsg := []byte("The quick brown fox jumps over the lazy dog")
+	shares, err := Split(secretMsg, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// from 2-of-3 to 3-of-5, with two of the old holders
+	newXCoordinates := []byte{10, 20, 30, 40, 50}
+	received := make([][][]byte, len(newXCoordinates))
+	for _, share := range [][]byte{shares[0], shares[2]} {
+		subShares, err := ReshareSplit(share, newXCoordinates, 3, csprng.NewCSPRNG())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j := range subShares {
+			received[j] = append(received[j], subShares[j])
+		}
+	}
+	newShares := make([][]byte, len(newXCoordinates))
+	for j := range newShares {
+		if newShares[j], err = ReshareCombine(received[j]); err != nil {
+			t.Fatal(err)
+		}
+		if newShares[j][len(secretMsg)] != newXCoordinates[j] {
+			t.Errorf("expecting the new share at x=%d, but got x=%d", newXCoordinates[j], newShares[j][len(secretMsg)])
+		}
+	}
+
+	combinedShares, err := Combine(newShares[2:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(secretMsg, combinedShares) {
+		t.Errorf("The combined secret is different. Expected: '%v', but got '%v'.\n", string(secretMsg), string(combinedShares))
+	}
+	if combinedShares, _ = Combine(newShares[:2]); reflect.DeepEqual(secretMsg, combinedShares) {
+		t.Errorf("the secret is combined from less than the new threshold")
+	}
+
+	if _, err := ReshareCombine([][]byte{received[0][0], received[1][1]}); err == nil {
+		t.Errorf("expecting an error when combining sub-shares for different new holders")
+	}
+}
+
+func TestEnrollment(t *testing.T) {
+	secretMsg := []byte("The quick brown fox jumps over the lazy dog")
+	shares, err := Split(secretMsg, 4, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	N := len(secretMsg)
+	holders := shares[1:]
+	participants := make([]byte, len(holders))
+	for i, share := range holders {
+		participants[i] = share[N]
+	}
+	newX := byte(0)
+	for x := 1; newX == 0; x++ {
+		if !bytes.Contains(append(participants, shares[0][N]), []byte{byte(x)}) {
+			newX = byte(x)
+		}
+	}
+
+	// masks[i] are the masks sent and received by participant i
+	masks := make([][][]byte, len(holders))
+	for i := range holders {
+		sent, err := NewEnrollmentMasks(participants, participants[i], N, csprng.NewCSPRNG())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j, mask := range sent {
+			if mask != nil {
+				masks[i] = append(masks[i], mask)
+				masks[j] = append(masks[j], mask)
+			}
+		}
+	}
+	partials := make([][]byte, len(holders))
+	for i, share := range holders {
+		if partials[i], err = EnrollmentPartial(share, participants, newX, masks[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	newShare, err := EnrollmentCombine(partials)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := RegenerateAt(holders, []byte{newX})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expected[0], newShare) {
+		t.Errorf("the enrolled share is different. Expected: '%v', but got '%v'.\n", expected[0], newShare)
+	}
+	combinedShares, err := Combine([][]byte{shares[0], shares[1], newShare})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(secretMsg, combinedShares) {
+		t.Errorf("The combined secret is different. Expected: '%v', but got '%v'.\n", string(secretMsg), string(combinedShares))
+	}
+
+	if _, err := EnrollmentPartial(holders[0], participants, newX, masks[0][1:]); err == nil {
+		t.Errorf("expecting an error when a mask is missing")
+	}
+	if _, err := EnrollmentPartial(holders[0], participants, participants[1], masks[0]); err == nil {
+		t.Errorf("expecting an error when enrolling an existing holder")
+	}
+}
+
+func TestSplitCombineWeighted(t *testing.T) {
+	secretMsg := []byte("The quick brown fox jumps over the lazy dog")
+	weights := map[string]int{"alice": 2, "bob": 2, "carol": 1, "dave": 1}
+	bundles, effective, err := SplitWeighted(secretMsg, weights, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedEffective := map[string]int{"alice": 1, "bob": 1, "carol": 2, "dave": 2}
+	if !reflect.DeepEqual(expectedEffective, effective) {
+		t.Errorf("expecting the effective thresholds %v, but got %v", expectedEffective, effective)
+	}
+
+	for _, holders := range [][]string{{"alice", "bob"}, {"alice", "carol"}, {"bob", "carol", "dave"}} {
+		var presented [][]byte
+		for _, holder := range holders {
+			presented = append(presented, bundles[holder])
+		}
+		combinedShares, err := CombineWeighted(presented)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(secretMsg, combinedShares) {
+			t.Errorf("The combined secret of %v is different. Expected: '%v', but got '%v'.\n", holders, string(secretMsg), string(combinedShares))
+		}
+	}
+	if combinedShares, _ := CombineWeighted([][]byte{bundles["carol"], bundles["dave"]}); reflect.DeepEqual(secretMsg, combinedShares) {
+		t.Errorf("the secret is combined from less than the threshold weight")
+	}
+
+	// the x coordinates chosen by the caller
+	bundles, _, err = SplitWeightedAt(secretMsg, map[string][]byte{"alice": {1, 2}, "bob": {3}}, 3, csprng.NewCSPRNG())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x := bundles["bob"][len(secretMsg)]; x != 3 {
+		t.Errorf("expecting the share of bob at x=3, but got x=%d", x)
+	}
+	combinedShares, err := CombineWeighted([][]byte{bundles["bob"], bundles["alice"]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(secretMsg, combinedShares) {
+		t.Errorf("The combined secret is different. Expected: '%v', but got '%v'.\n", string(secretMsg), string(combinedShares))
+	}
+	if _, _, err := SplitWeightedAt(secretMsg, map[string][]byte{"alice": {1, 2}, "bob": {2}}, 2, nil); err == nil {
+		t.Errorf("expecting an error for a duplicate x coordinate")
+	}
+}
+
+func TestSplitCombineRamp(t *testing.T) {
+	secretMsg := []byte("The quick brown fox jumps over the lazy dog")
+	randomizer := csprng.NewCSPRNG()
+
+	for _, c := range []struct{ parts, privacy, packing int }{
+		{5, 1, 1}, {6, 2, 3}, {10, 3, 4}, {20, 4, 16}, {60, 2, 43}, {200, 5, 56},
+	} {
+		shares, err := SplitRampWithRandomizer(secretMsg, c.parts, c.privacy, c.packing, randomizer)
+		if err != nil {
+			t.Fatalf("failed to split with %+v: %v", c, err)
+		}
+		expectedLen := (len(secretMsg)+c.packing-1)/c.packing + RampShareOverhead
+		if len(shares[0]) != expectedLen {
+			t.Errorf("the expected length of a single share is %d, but got %d", expectedLen, len(shares[0]))
+		}
+
+		threshold := c.privacy + c.packing
+		for trial := 0; trial < 10; trial++ {
+			rand.Shuffle(len(shares), func(i, j int) { shares[i], shares[j] = shares[j], shares[i] })
+			combined, err := CombineRamp(shares[:threshold])
+			if err != nil {
+				t.Fatalf("failed to combine with %+v: %v", c, err)
+			}
+			if !reflect.DeepEqual(secretMsg, combined) {
+				t.Errorf("The combined secret is different. Expected: '%v', but got '%v'.\n", string(secretMsg), string(combined))
+			}
+		}
+		if _, err := CombineRamp(shares[:threshold-1]); err == nil {
+			t.Errorf("expecting an error when combining less than privacy+packing shares")
+		}
+	}
+
+	if _, err := SplitRamp(secretMsg, 255, 2, 2); err == nil {
+		t.Errorf("expecting an error when the parts overlap the x of the stripes")
+	}
+	if _, err := SplitRamp(secretMsg, 3, 2, 2); err == nil {
+		t.Errorf("expecting an error when parts is less than privacy+packing")
+	}
+}
+
+func TestRampPrivacy(t *testing.T) {
+	// with privacy 2 and packing 1 the shares are those of shamir with
+	// threshold 3: any two of them are consistent with every secret
+	secretMsg := []byte{42}
+	shares, err := SplitRamp(secretMsg, 5, 2, 1)
+	if err != nil {
+		t.Fatalf("failed to split the message: %v", err)
+	}
+	N := len(shares[0]) - RampShareOverhead
+	for s := 0; s < 256; s++ {
+		forged := append([]byte(nil), shares[0]...)
+		xs := []byte{rampSecretX(0), shares[0][N+6], shares[1][N+6]}
+		ys := []byte{byte(s), shares[0][0], shares[1][0]}
+		forged[0] = interpolatePolynomial(xs, ys, shares[2][N+6])
+		forged[N+6] = shares[2][N+6]
+		combined, err := CombineRamp([][]byte{shares[0], shares[1], forged})
+		if err != nil {
+			t.Fatalf("failed to combine the message: %v", err)
+		}
+		if combined[0] != byte(s) {
+			t.Errorf("The combined secret is different. Expected: '%v', but got '%v'.\n", s, combined[0])
+		}
+	}
+}
+
+func TestSplitCombineXOR(t *testing.T) {
+	secretMsg := []byte("The quick brown fox jumps over the lazy dog")
+	for _, parts := range []int{1, 2, 5, 255} {
+		shares, err := SplitXOR(secretMsg, parts, csprng.NewCSPRNG())
+		if err != nil {
+			t.Fatalf("failed to split the message: %v", err)
+		}
+		rand.Shuffle(len(shares), func(i, j int) { shares[i], shares[j] = shares[j], shares[i] })
+		combined, err := CombineXOR(shares)
+		if err != nil {
+			t.Fatalf("failed to combine the message: %v", err)
+		}
+		if !reflect.DeepEqual(secretMsg, combined) {
+			t.Errorf("The combined secret is different. Expected: '%v', but got '%v'.\n", string(secretMsg), string(combined))
+		}
+		if parts > 1 {
+			if _, err := CombineXOR(shares[1:]); err == nil {
+				t.Errorf("expecting an error when combining less than all the parts")
+			}
+			if _, err := CombineXOR(append([][]byte{shares[0]}, shares[:parts-1]...)); err == nil {
+				t.Errorf("expecting an error when combining duplicate parts")
+			}
+		}
+	}
+	if _, err := SplitXOR(secretMsg, 0, nil); err == nil {
+		t.Errorf("expecting an error when splitting into zero parts")
+	}
+}
+
+func TestLinearCombination(t *testing.T) {
+	// three holders aggregate shared counters without reconstructing them
+	counters := [][]byte{{1, 2, 3, 4}, {16, 32, 64, 128}, {7, 7, 7, 7}}
+	coeffs := []byte{1, 3, 200}
+	xCoordinates := []byte{9, 42, 170}
+	r := csprng.NewCSPRNG()
+
+	shares := make([][][]byte, len(counters))
+	for i, counter := range counters {
+		var err error
+		if shares[i], err = SplitAt(counter, xCoordinates, 2, r); err != nil {
+			t.Fatalf("failed to split the counter: %v", err)
+		}
+	}
+
+	expectedSum := make([]byte, 4)
+	expected := make([]byte, 4)
+	for i, counter := range counters {
+		for j := range counter {
+			expectedSum[j] ^= counter[j]
+			expected[j] ^= gf.GalMultiply(coeffs[i], counter[j])
+		}
+	}
+
+	sums := make([][]byte, len(xCoordinates))
+	combinations := make([][]byte, len(xCoordinates))
+	for h := range xCoordinates {
+		var err error
+		if sums[h], err = AddShares(shares[0][h], shares[1][h]); err != nil {
+			t.Fatalf("failed to add the shares: %v", err)
+		}
+		if sums[h], err = AddShares(sums[h], shares[2][h]); err != nil {
+			t.Fatalf("failed to add the shares: %v", err)
+		}
+		holderShares := [][]byte{shares[0][h], shares[1][h], shares[2][h]}
+		if combinations[h], err = LinearCombination(holderShares, coeffs); err != nil {
+			t.Fatalf("failed to combine the shares linearly: %v", err)
+		}
+	}
+
+	combinedSum, _ := Combine(sums[1:])
+	if !reflect.DeepEqual(expectedSum, combinedSum) {
+		t.Errorf("The combined secret is different. Expected: '%v', but got '%v'.\n", expectedSum, combinedSum)
+	}
+	combined, _ := Combine(combinations[:2])
+	if !reflect.DeepEqual(expected, combined) {
+		t.Errorf("The combined secret is different. Expected: '%v', but got '%v'.\n", expected, combined)
+	}
+
+	scaled := make([][]byte, 2)
+	for h := range scaled {
+		scaled[h], _ = ScaleShare(shares[1][h], 3)
+	}
+	combinedScaled, _ := Combine(scaled)
+	expectedScaled := make([]byte, 4)
+	for j := range expectedScaled {
+		expectedScaled[j] = gf.GalMultiply(3, counters[1][j])
+	}
+	if !reflect.DeepEqual(expectedScaled, combinedScaled) {
+		t.Errorf("The combined secret is different. Expected: '%v', but got '%v'.\n", expectedScaled, combinedScaled)
+	}
+
+	if _, err := AddShares(shares[0][0], shares[1][1]); err == nil {
+		t.Errorf("expecting an error when adding shares at different x coordinates")
+	}
+	if _, err := LinearCombination(shares[0][:1], coeffs); err == nil {
+		t.Errorf("expecting an error when the number of coefficients is different")
+	}
+}
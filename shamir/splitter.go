@@ -0,0 +1,186 @@
+package shamir
+
+import (
+	"fmt"
+	"github.com/fadhilkurnia/shamir/csprng"
+	gf "github.com/fadhilkurnia/shamir/galois"
+	"math/rand"
+)
+
+// splitBlockSize is the size of the random coefficients generated at once,
+// the columns of the secret are processed in blocks so the coefficients
+// stay in the cache while being multiplied with the powers of every x.
+const splitBlockSize = 32 << 10
+
+// Splitter does shamir's secret-sharing at fixed x coordinates. The powers
+// of each x coordinate are computed once, so each split is a multiplication
+// of the Vandermonde matrix with the coefficient matrix, done block by block
+// with the vector kernels. A Splitter is safe for concurrent use, as long
+// as each goroutine uses its own randomizer.
+type Splitter struct {
+	threshold    int
+	xCoordinates []byte
+
+	// powers[i][d-1] is the d-th power of xCoordinates[i], for d in [1, threshold)
+	powers [][]byte
+}
+
+// Option configures a Splitter.
+type Option func(*Splitter)
+
+// WithXCoordinates makes the Splitter generate the shares at the given
+// x coordinates, one share for each x coordinate. The x coordinates must
+// be unique and non-zero. By default, the shares are at x=1, 2, .., parts.
+func WithXCoordinates(xCoordinates []byte) Option {
+	return func(s *Splitter) {
+		s.xCoordinates = append([]byte(nil), xCoordinates...)
+	}
+}
+
+// NewSplitter creates a Splitter that generates `parts` shares, `threshold`
+// of which are required to reconstruct the secret.
+func NewSplitter(parts, threshold int, opts ...Option) (*Splitter, error) {
+	// Sanity check the input
+	if parts < threshold {
+		return nil, fmt.Errorf("parts cannot be less than threshold")
+	}
+	if parts > 255 {
+		return nil, fmt.Errorf("parts cannot exceed 255")
+	}
+	if threshold < 2 {
+		return nil, fmt.Errorf("threshold must be at least 2")
+	}
+	if threshold > 255 {
+		return nil, fmt.Errorf("threshold cannot exceed 255")
+	}
+
+	s := &Splitter{threshold: threshold}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.xCoordinates == nil {
+		s.xCoordinates = make([]byte, parts)
+		for i := range s.xCoordinates {
+			s.xCoordinates[i] = byte(i + 1)
+		}
+	}
+	if len(s.xCoordinates) != parts {
+		return nil, fmt.Errorf("expecting %d x coordinates, but got %d", parts, len(s.xCoordinates))
+	}
+	if err := checkXCoordinates(s.xCoordinates); err != nil {
+		return nil, err
+	}
+
+	s.powers = computePowers(s.xCoordinates, threshold-1)
+	return s, nil
+}
+
+// computePowers returns the first `degree` powers of each x coordinate.
+func computePowers(xCoordinates []byte, degree int) [][]byte {
+	powers := newMatrix(len(xCoordinates), degree)
+	for i, x := range xCoordinates {
+		xPow := uint8(1)
+		for d := 0; d < degree; d++ {
+			xPow = gf.GalMultiply(xPow, x)
+			powers[i][d] = xPow
+		}
+	}
+	return powers
+}
+
+// XCoordinates returns the x coordinates of the shares, in the order of the shares.
+func (s *Splitter) XCoordinates() []byte {
+	return append([]byte(nil), s.xCoordinates...)
+}
+
+// Split secret-shares the secret using math/rand as the randomization source.
+func (s *Splitter) Split(secret []byte) ([][]byte, error) {
+	return s.split(secret, nil)
+}
+
+// SplitWithRandomizer is exactly the same with Split but with randomizer
+// provided by the caller.
+func (s *Splitter) SplitWithRandomizer(secret []byte, randomizer *csprng.CSPRNG) ([][]byte, error) {
+	return s.split(secret, randomizer)
+}
+
+func (s *Splitter) split(secret []byte, randomizer *csprng.CSPRNG) ([][]byte, error) {
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("cannot split an empty secret")
+	}
+	return splitWithPowers(secret, s.xCoordinates, s.powers, randomizer)
+}
+
+// splitWithPowers generates the shares at the given x coordinates, whose
+// powers are already computed.
+func splitWithPowers(secret []byte, xCoordinates []byte, powers [][]byte, randomizer *csprng.CSPRNG) ([][]byte, error) {
+	out := newShares(len(secret), xCoordinates)
+	if err := evaluateStripe(secret, 0, len(secret), powers, randomizer, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// newShares allocates the shares of an N bytes secret,
+// each share is {y1, y2, .., yN, x}.
+func newShares(N int, xCoordinates []byte) [][]byte {
+	parts := len(xCoordinates)
+	out := make([][]byte, parts)
+	buff := make([]byte, (N+1)*parts)
+	for i := range out {
+		out[i] = buff[i*(N+1) : (i+1)*(N+1) : (i+1)*(N+1)]
+		out[i][N] = xCoordinates[i]
+	}
+	return out
+}
+
+// evaluateStripe generates the shares of secret[from:to] into out[i][from:to].
+func evaluateStripe(secret []byte, from, to int, powers [][]byte, randomizer *csprng.CSPRNG, out [][]byte) error {
+	parts := len(powers)
+	degree := len(powers[0])
+
+	// the width of each block, such that the random
+	// coefficients of a block fit in splitBlockSize
+	width := splitBlockSize / degree
+	if width < 64 {
+		width = 64
+	}
+	if width > to-from {
+		width = to - from
+	}
+
+	coeffBuff, putCoeffBuff := getScratch(degree * width)
+	defer putCoeffBuff()
+
+	for start := from; start < to; start += width {
+		end := start + width
+		if end > to {
+			end = to
+		}
+		w := end - start
+
+		// Row d of the block holds the (d+1)-th coefficient of the
+		// polynomials, the intercepts are the secret itself
+		coefficients := coeffBuff[0 : degree*w]
+		var err error
+		if randomizer != nil {
+			_, err = randomizer.Read(coefficients)
+		} else {
+			_, err = rand.Read(coefficients)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to generate polynomial: %v", err)
+		}
+
+		// y_i = secret + sum_d x_i^d * coefficients_d
+		for i := 0; i < parts; i++ {
+			y := out[i][start:end]
+			copy(y, secret[start:end])
+			for d := 0; d < degree; d++ {
+				gf.MulAddVector(powers[i][d], coefficients[d*w:(d+1)*w], y)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package shamir
+
+import (
+	"fmt"
+	"github.com/fadhilkurnia/shamir/csprng"
+	"sort"
+)
+
+// SplitWeighted secret-shares the secret among named holders, each holder
+// counting as many times as its weight: it gets as many shares as its
+// weight, bundled into a single multi-point share, and any set of holders
+// whose weights add up to `threshold` can reconstruct the secret with
+// CombineWeighted. The shares are at random x coordinates.
+//
+// Along with the bundles, the effective threshold of each holder is
+// returned: the total weight of the other holders still required with it,
+// zero when its weight alone reaches the threshold.
+func SplitWeighted(secret []byte, weights map[string]int, threshold int) (map[string][]byte, map[string]int, error) {
+	return SplitWeightedWithRandomizer(secret, weights, threshold, nil)
+}
+
+// SplitWeightedWithRandomizer is exactly the same with SplitWeighted but
+// with randomizer provided by the caller.
+func SplitWeightedWithRandomizer(secret []byte, weights map[string]int, threshold int, randomizer *csprng.CSPRNG) (map[string][]byte, map[string]int, error) {
+	total := 0
+	holders := make([]string, 0, len(weights))
+	for holder, weight := range weights {
+		if weight < 1 {
+			return nil, nil, fmt.Errorf("the weight of %q must be at least 1", holder)
+		}
+		total += weight
+		holders = append(holders, holder)
+	}
+	sort.Strings(holders)
+	if total > 255 {
+		return nil, nil, fmt.Errorf("the total weight cannot exceed 255")
+	}
+
+	xs := randomXCoordinates(total, randomizer)
+	xCoordinates := make(map[string][]byte, len(weights))
+	for _, holder := range holders {
+		xCoordinates[holder] = xs[:weights[holder]:weights[holder]]
+		xs = xs[weights[holder]:]
+	}
+	return SplitWeightedAt(secret, xCoordinates, threshold, randomizer)
+}
+
+// SplitWeightedAt is similar with SplitWeighted, but the shares of each
+// holder are at the x coordinates chosen by the caller, its weight is the
+// number of its x coordinates. The x coordinates of all the holders must be
+// unique and non-zero. When randomizer is nil, math/rand is used.
+func SplitWeightedAt(secret []byte, xCoordinates map[string][]byte, threshold int, randomizer *csprng.CSPRNG) (map[string][]byte, map[string]int, error) {
+	if len(xCoordinates) == 0 {
+		return nil, nil, fmt.Errorf("no holder is given")
+	}
+	holders := make([]string, 0, len(xCoordinates))
+	for holder := range xCoordinates {
+		holders = append(holders, holder)
+	}
+	sort.Strings(holders)
+	var xs []byte
+	for _, holder := range holders {
+		if len(xCoordinates[holder]) == 0 {
+			return nil, nil, fmt.Errorf("holder %q has no x coordinate", holder)
+		}
+		xs = append(xs, xCoordinates[holder]...)
+	}
+	shares, err := SplitAt(secret, xs, threshold, randomizer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// each bundle is {share 1, share 2, .., share k, k}
+	bundles := make(map[string][]byte, len(holders))
+	effectiveThresholds := make(map[string]int, len(holders))
+	for _, holder := range holders {
+		weight := len(xCoordinates[holder])
+		bundle := make([]byte, 0, weight*(len(secret)+1)+1)
+		for _, share := range shares[:weight] {
+			bundle = append(bundle, share...)
+		}
+		bundles[holder] = append(bundle, byte(weight))
+		shares = shares[weight:]
+
+		effectiveThresholds[holder] = threshold - weight
+		if effectiveThresholds[holder] < 0 {
+			effectiveThresholds[holder] = 0
+		}
+	}
+	return bundles, effectiveThresholds, nil
+}
+
+// CombineWeighted reconstructs the secret from the bundles of holders whose
+// weights add up to the threshold.
+func CombineWeighted(bundles [][]byte) ([]byte, error) {
+	var parts [][]byte
+	for i, bundle := range bundles {
+		shares, err := unbundle(bundle)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bundle %d: %v", i, err)
+		}
+		parts = append(parts, shares...)
+	}
+	return Combine(parts)
+}
+
+// unbundle returns the shares of a bundle.
+func unbundle(bundle []byte) ([][]byte, error) {
+	if len(bundle) == 0 {
+		return nil, fmt.Errorf("the bundle is empty")
+	}
+	k := int(bundle[len(bundle)-1])
+	if k == 0 || (len(bundle)-1)%k != 0 || (len(bundle)-1)/k < 2 {
+		return nil, fmt.Errorf("the bundle is malformed")
+	}
+	shareLen := (len(bundle) - 1) / k
+	shares := make([][]byte, k)
+	for i := range shares {
+		shares[i] = bundle[i*shareLen : (i+1)*shareLen : (i+1)*shareLen]
+	}
+	return shares, nil
+}
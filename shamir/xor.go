@@ -0,0 +1,92 @@
+package shamir
+
+import (
+	"fmt"
+	"github.com/fadhilkurnia/shamir/csprng"
+	gf "github.com/fadhilkurnia/shamir/galois"
+	"math/rand"
+)
+
+// XORShareOverhead is the byte size of the trailer of a SplitXOR share:
+// the index of the share and the number of parts.
+const XORShareOverhead = 2
+
+// SplitXOR splits the secret into `parts` shares, all of which are required
+// to reconstruct it with CombineXOR: the first parts-1 shares are random
+// pads and the last one is the secret XOR-ed with the pads. This is the
+// n-of-n case of Split, without any polynomial evaluation. When randomizer
+// is nil, math/rand is used.
+func SplitXOR(secret []byte, parts int, randomizer *csprng.CSPRNG) ([][]byte, error) {
+	if parts < 1 {
+		return nil, fmt.Errorf("parts must be at least 1")
+	}
+	if parts > 255 {
+		return nil, fmt.Errorf("parts cannot exceed 255")
+	}
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("cannot split an empty secret")
+	}
+
+	N := len(secret)
+	shareLen := N + XORShareOverhead
+	buff := make([]byte, parts*shareLen)
+	shares := make([][]byte, parts)
+	for i := range shares {
+		shares[i] = buff[i*shareLen : (i+1)*shareLen : (i+1)*shareLen]
+	}
+
+	var err error
+	if randomizer != nil {
+		_, err = randomizer.Read(buff[:(parts-1)*shareLen])
+	} else {
+		_, err = rand.Read(buff[:(parts-1)*shareLen])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate the pads: %v", err)
+	}
+	last := shares[parts-1][:N]
+	copy(last, secret)
+	for i, share := range shares {
+		if i < parts-1 {
+			gf.AddVector(share[:N], last)
+		}
+		share[N] = byte(i)
+		share[N+1] = byte(parts)
+	}
+	return shares, nil
+}
+
+// CombineXOR reconstructs the secret from all the shares of SplitXOR,
+// in any order.
+func CombineXOR(parts [][]byte) ([]byte, error) {
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("no part is given")
+	}
+	partLen := len(parts[0])
+	if partLen <= XORShareOverhead {
+		return nil, fmt.Errorf("parts must be at least %d bytes", XORShareOverhead+1)
+	}
+	N := partLen - XORShareOverhead
+	numParts := int(parts[0][N+1])
+	if len(parts) != numParts {
+		return nil, fmt.Errorf("expecting all the %d parts, but got %d", numParts, len(parts))
+	}
+
+	seen := make([]bool, numParts)
+	secret := make([]byte, N)
+	for _, part := range parts {
+		if len(part) != partLen {
+			return nil, fmt.Errorf("all parts must be the same length")
+		}
+		index := int(part[N])
+		if int(part[N+1]) != numParts || index >= numParts {
+			return nil, fmt.Errorf("all parts must be split from the same secret")
+		}
+		if seen[index] {
+			return nil, fmt.Errorf("duplicate part detected")
+		}
+		seen[index] = true
+		gf.AddVector(part[:N], secret)
+	}
+	return secret, nil
+}
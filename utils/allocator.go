@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// Allocator allocates the memory of SecretBuffers.
+type Allocator interface {
+	// Alloc returns a zeroed SecretBuffer of size bytes.
+	Alloc(size int) *SecretBuffer
+}
+
+type heapAllocator struct{}
+
+func (heapAllocator) Alloc(size int) *SecretBuffer {
+	return &SecretBuffer{b: make([]byte, size)}
+}
+
+// HeapAllocator allocates SecretBuffers in the garbage-collected heap.
+var HeapAllocator Allocator = heapAllocator{}
+
+var (
+	allocatorMu sync.RWMutex
+	allocator   Allocator
+)
+
+// SetAllocator makes NewSecretBuffer allocate with a, and makes the
+// secret-sharing packages draw their scratch and output for secret-bearing
+// data from it. A nil allocator restores the default: SecretBuffers are
+// allocated in the heap and the scratch comes from the wiped buffer pools.
+func SetAllocator(a Allocator) {
+	allocatorMu.Lock()
+	defer allocatorMu.Unlock()
+	allocator = a
+}
+
+// SecretAllocator returns the allocator set by SetAllocator,
+// nil when none is set.
+func SecretAllocator() Allocator {
+	allocatorMu.RLock()
+	defer allocatorMu.RUnlock()
+	return allocator
+}
+
+// maxFreeLocked is the number of released mappings a LockedAllocator
+// keeps for reuse, sparing the system calls of small frequent allocations.
+const maxFreeLocked = 64
+
+// LockedAllocator allocates SecretBuffers in memory that is locked into
+// RAM, thus never swapped to disk, and excluded from core dumps. Each
+// buffer is a private anonymous mapping of whole pages. When the memory
+// can not be locked, for example when RLIMIT_MEMLOCK is too low or on
+// systems other than Linux, the buffers fall back to the heap, see
+// Fallbacks. The released mappings are wiped and kept for reuse.
+// A LockedAllocator is safe for concurrent use.
+type LockedAllocator struct {
+	fallbacks uint64
+
+	mu       sync.Mutex
+	free     map[int][][]byte
+	numFree  int
+	pageSize int
+}
+
+// NewLockedAllocator creates a LockedAllocator.
+func NewLockedAllocator() *LockedAllocator {
+	return &LockedAllocator{
+		free:     map[int][][]byte{},
+		pageSize: os.Getpagesize(),
+	}
+}
+
+// Alloc returns a zeroed SecretBuffer of size bytes, locked into memory
+// when possible.
+func (a *LockedAllocator) Alloc(size int) *SecretBuffer {
+	if size <= 0 {
+		return HeapAllocator.Alloc(size)
+	}
+	mappingSize := (size + a.pageSize - 1) / a.pageSize * a.pageSize
+
+	a.mu.Lock()
+	var m []byte
+	if free := a.free[mappingSize]; len(free) > 0 {
+		m = free[len(free)-1]
+		a.free[mappingSize] = free[:len(free)-1]
+		a.numFree--
+	}
+	a.mu.Unlock()
+
+	if m == nil {
+		var err error
+		if m, err = lockedAlloc(mappingSize); err != nil {
+			atomic.AddUint64(&a.fallbacks, 1)
+			return HeapAllocator.Alloc(size)
+		}
+	}
+	return &SecretBuffer{
+		b:       m[:size:size],
+		release: func() { a.release(m) },
+	}
+}
+
+// release wipes the mapping, then keeps it for reuse or unmaps it.
+func (a *LockedAllocator) release(m []byte) {
+	Wipe(m)
+	a.mu.Lock()
+	if a.numFree < maxFreeLocked {
+		a.free[len(m)] = append(a.free[len(m)], m)
+		a.numFree++
+		a.mu.Unlock()
+		return
+	}
+	a.mu.Unlock()
+	lockedFree(m)
+}
+
+// Fallbacks returns how many SecretBuffers were allocated in the heap
+// since the memory could not be locked.
+func (a *LockedAllocator) Fallbacks() uint64 {
+	return atomic.LoadUint64(&a.fallbacks)
+}
+
+// Close unmaps the mappings kept for reuse. The LockedAllocator can still
+// be used after Close.
+func (a *LockedAllocator) Close() {
+	a.mu.Lock()
+	free := a.free
+	a.free = map[int][][]byte{}
+	a.numFree = 0
+	a.mu.Unlock()
+	for _, mappings := range free {
+		for _, m := range mappings {
+			lockedFree(m)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+func TestLockedAllocator(t *testing.T) {
+	secret := []byte("The quick brown fox jumps over the lazy dog.")
+	a := NewLockedAllocator()
+	defer a.Close()
+
+	s := a.Alloc(len(secret))
+	if s.Len() != len(secret) || !isZero(s.Bytes()) {
+		t.Fatalf("expecting %d zeroed bytes, but got %v", len(secret), s.Bytes())
+	}
+	if a.Fallbacks() == 0 && !s.Locked() {
+		t.Errorf("the secret buffer is not locked, but no fallback is counted")
+	}
+	copy(s.Bytes(), secret)
+	s.Destroy()
+	s.Destroy()
+
+	// the released mapping is reused, without the previous contents
+	for i := 0; i < 3; i++ {
+		s = a.Alloc(len(secret) + i)
+		if !isZero(s.Bytes()[:cap(s.Bytes())]) {
+			t.Errorf("the allocator leaks the previous contents of a buffer: %v", s.Bytes())
+		}
+		copy(s.Bytes(), secret)
+		s.Destroy()
+	}
+
+	if s := a.Alloc(0); s.Len() != 0 || s.Locked() {
+		t.Errorf("expecting an empty heap buffer, but got %d bytes, locked %v", s.Len(), s.Locked())
+	}
+}
+
+func TestLockedAllocatorConcurrent(t *testing.T) {
+	secret := []byte("The quick brown fox jumps over the lazy dog.")
+	a := NewLockedAllocator()
+	defer a.Close()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				s := a.Alloc(len(secret) + 4096*(i%3))
+				copy(s.Bytes(), secret)
+				if !bytes.Equal(s.Bytes()[:len(secret)], secret) {
+					t.Errorf("the secret buffer is shared with another goroutine")
+				}
+				s.Destroy()
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+func TestSetAllocator(t *testing.T) {
+	a := NewLockedAllocator()
+	defer a.Close()
+	SetAllocator(a)
+	defer SetAllocator(nil)
+
+	s := NewSecretBuffer(32)
+	if a.Fallbacks() == 0 && !s.Locked() {
+		t.Errorf("NewSecretBuffer does not use the allocator set with SetAllocator")
+	}
+	s.Destroy()
+
+	SetAllocator(nil)
+	if s := NewSecretBuffer(32); s.Locked() {
+		t.Errorf("NewSecretBuffer uses the locked allocator after restoring the default")
+	}
+}
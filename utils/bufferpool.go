@@ -7,6 +7,9 @@ import (
 
 type BytesBufferPool struct {
 	ch chan *bytes.Buffer
+
+	// wipe makes Put zero the buffers, see NewSecureBytesBufferPool
+	wipe bool
 }
 
 func NewBytesBufferPool(size int)  *BytesBufferPool {
@@ -18,6 +21,15 @@ func NewBytesBufferPool(size int)  *BytesBufferPool {
 	}
 }
 
+// NewSecureBytesBufferPool is similar with NewBytesBufferPool, but every
+// buffer is wiped when put back, thus Get never returns the contents left
+// by a previous user. Used for the buffers holding secret material.
+func NewSecureBytesBufferPool(size int) *BytesBufferPool {
+	b := NewBytesBufferPool(size)
+	b.wipe = true
+	return b
+}
+
 func (b *BytesBufferPool) Get() *bytes.Buffer {
 	select {
 	case bb := <- b.ch:
@@ -28,6 +40,10 @@ func (b *BytesBufferPool) Get() *bytes.Buffer {
 }
 
 func (b *BytesBufferPool) Put(bb *bytes.Buffer)  {
+	// wipe the buffer even when it is discarded
+	if b.wipe {
+		WipeBuffer(bb)
+	}
 	select {
 	case b.ch <- bb:
 		return
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"bytes"
+	"testing"
+)
+
+func isZero(b []byte) bool {
+	for _, v := range b {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSecureBytesBufferPool(t *testing.T) {
+	secret := []byte("The quick brown fox jumps over the lazy dog.")
+	p := NewSecureBytesBufferPool(4)
+
+	// fill the pool with used buffers, some of them partially read
+	for i := 0; i < 4; i++ {
+		bb := p.Get()
+		bb.Write(secret)
+		bb.Next(i)
+		bb.Grow(100 * i)
+		p.Put(bb)
+	}
+	for i := 0; i < 4; i++ {
+		bb := p.Get()
+		if bb.Len() != 0 || !isZero(bb.Bytes()[:bb.Cap()]) {
+			t.Errorf("the pool leaks the previous contents of a buffer: %v", bb.Bytes()[:bb.Cap()])
+		}
+	}
+
+	// a buffer discarded by a full pool is wiped as well
+	full := NewSecureBytesBufferPool(1)
+	full.Put(&bytes.Buffer{})
+	bb := bytes.NewBuffer(append([]byte(nil), secret...))
+	full.Put(bb)
+	if !isZero(bb.Bytes()[:bb.Cap()]) {
+		t.Errorf("the discarded buffer is not wiped: %v", bb.Bytes()[:bb.Cap()])
+	}
+}
+
+func TestSecretBuffer(t *testing.T) {
+	secret := []byte("The quick brown fox jumps over the lazy dog.")
+	copied := append([]byte(nil), secret...)
+
+	s := NewSecretBufferFrom(copied)
+	if !isZero(copied) {
+		t.Errorf("the source of the secret buffer is not wiped: %v", copied)
+	}
+	if !bytes.Equal(s.Bytes(), secret) {
+		t.Errorf("The secret is different. Expected: '%v', but got '%v'.\n", string(secret), string(s.Bytes()))
+	}
+
+	b := s.Bytes()
+	s.Truncate(9)
+	if s.Len() != 9 || !bytes.Equal(s.Bytes(), secret[:9]) || !isZero(b[9:]) {
+		t.Errorf("expecting only '%s' after truncating, but got %v", secret[:9], b)
+	}
+	s.Destroy()
+	if s.Len() != 0 || !isZero(b) {
+		t.Errorf("the secret buffer is not wiped after Destroy: %v", b)
+	}
+	s.Destroy()
+}
@@ -0,0 +1,43 @@
+//go:build linux
+// +build linux
+
+package utils
+
+import "golang.org/x/sys/unix"
+
+// lockedAlloc maps size bytes of anonymous memory, locked into RAM and
+// excluded from core dumps. Locking fails with ENOMEM or EPERM when it
+// would exceed RLIMIT_MEMLOCK.
+func lockedAlloc(size int) ([]byte, error) {
+	m, err := unix.Mmap(-1, 0, size, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_PRIVATE|unix.MAP_ANONYMOUS)
+	if err != nil {
+		return nil, err
+	}
+	if err := unix.Mlock(m); err != nil {
+		_ = unix.Munmap(m)
+		return nil, err
+	}
+	if err := unix.Madvise(m, unix.MADV_DONTDUMP); err != nil {
+		_ = unix.Munlock(m)
+		_ = unix.Munmap(m)
+		return nil, err
+	}
+	return m, nil
+}
+
+// lockedFree wipes, unlocks and unmaps a mapping from lockedAlloc.
+func lockedFree(m []byte) {
+	Wipe(m)
+	_ = unix.Munlock(m)
+	_ = unix.Munmap(m)
+}
+
+// MemlockLimit returns the soft RLIMIT_MEMLOCK, the number of bytes
+// the process can lock into memory.
+func MemlockLimit() (uint64, error) {
+	var rlim unix.Rlimit
+	if err := unix.Getrlimit(unix.RLIMIT_MEMLOCK, &rlim); err != nil {
+		return 0, err
+	}
+	return rlim.Cur, nil
+}
@@ -0,0 +1,42 @@
+//go:build linux
+// +build linux
+
+package utils
+
+import (
+	"golang.org/x/sys/unix"
+	"testing"
+)
+
+func TestLockedAllocatorFallback(t *testing.T) {
+	var rlim unix.Rlimit
+	if err := unix.Getrlimit(unix.RLIMIT_MEMLOCK, &rlim); err != nil {
+		t.Fatal(err)
+	}
+	defer unix.Setrlimit(unix.RLIMIT_MEMLOCK, &rlim)
+
+	// with a single locked page allowed, the next buffers are in the heap
+	pageSize := uint64(unix.Getpagesize())
+	if err := unix.Setrlimit(unix.RLIMIT_MEMLOCK, &unix.Rlimit{Cur: pageSize, Max: rlim.Max}); err != nil {
+		t.Fatal(err)
+	}
+	if limit, err := MemlockLimit(); err != nil || limit != pageSize {
+		t.Errorf("expecting the memlock limit to be %d, but got %d, error %v", pageSize, limit, err)
+	}
+
+	a := NewLockedAllocator()
+	defer a.Close()
+	var buffers []*SecretBuffer
+	for i := 0; i < 4; i++ {
+		s := a.Alloc(int(pageSize))
+		s.Bytes()[0] = 1
+		buffers = append(buffers, s)
+	}
+	if a.Fallbacks() == 0 {
+		// privileged processes are not limited by RLIMIT_MEMLOCK
+		t.Skip("the memory is locked beyond RLIMIT_MEMLOCK, the process is privileged")
+	}
+	for _, s := range buffers {
+		s.Destroy()
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !linux
+// +build !linux
+
+package utils
+
+import "errors"
+
+var errLockUnsupported = errors.New("locked memory is only supported on linux")
+
+func lockedAlloc(size int) ([]byte, error) {
+	return nil, errLockUnsupported
+}
+
+func lockedFree(m []byte) {
+	Wipe(m)
+}
+
+// MemlockLimit returns the number of bytes the process can lock into
+// memory, which is always zero on this system.
+func MemlockLimit() (uint64, error) {
+	return 0, errLockUnsupported
+}
@@ -0,0 +1,65 @@
+package utils
+
+// SecretBuffer holds secret bytes, such as a reconstructed secret or a
+// key, until the owner destroys it. Destroy overwrites the bytes with
+// zeros, rather than leaving them in memory until the garbage collector
+// reuses it. A SecretBuffer is not safe for concurrent use.
+type SecretBuffer struct {
+	b []byte
+
+	// release returns the memory to the allocator once wiped,
+	// nil for the memory of the garbage-collected heap
+	release func()
+}
+
+// NewSecretBuffer allocates a zeroed SecretBuffer of size bytes,
+// with the allocator set by SetAllocator.
+func NewSecretBuffer(size int) *SecretBuffer {
+	if a := SecretAllocator(); a != nil {
+		return a.Alloc(size)
+	}
+	return HeapAllocator.Alloc(size)
+}
+
+// NewSecretBufferFrom copies secret into a new SecretBuffer,
+// then wipes secret.
+func NewSecretBufferFrom(secret []byte) *SecretBuffer {
+	s := NewSecretBuffer(len(secret))
+	copy(s.b, secret)
+	Wipe(secret)
+	return s
+}
+
+// Bytes returns the secret bytes, which are only valid until Destroy.
+func (s *SecretBuffer) Bytes() []byte {
+	return s.b
+}
+
+// Locked reports whether the secret bytes are locked into memory,
+// see LockedAllocator.
+func (s *SecretBuffer) Locked() bool {
+	return s.release != nil
+}
+
+// Len returns the number of secret bytes, zero once destroyed.
+func (s *SecretBuffer) Len() int {
+	return len(s.b)
+}
+
+// Truncate wipes the secret bytes beyond the first n bytes,
+// which are kept. It panics if n is larger than Len.
+func (s *SecretBuffer) Truncate(n int) {
+	Wipe(s.b[n:])
+	s.b = s.b[:n:n]
+}
+
+// Destroy wipes the secret bytes and releases their memory, which must
+// not be used anymore. Destroy can be called more than once.
+func (s *SecretBuffer) Destroy() {
+	Wipe(s.b)
+	s.b = nil
+	if s.release != nil {
+		s.release()
+		s.release = nil
+	}
+}
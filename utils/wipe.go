@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"bytes"
+	"runtime"
+)
+
+// Wipe overwrites b with zeros.
+func Wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+	runtime.KeepAlive(b)
+}
+
+// WipeMatrix overwrites every row of m with zeros.
+func WipeMatrix(m [][]byte) {
+	for _, row := range m {
+		Wipe(row)
+	}
+}
+
+// WipeBuffer overwrites the whole capacity of bb with zeros, including the
+// bytes already read and the bytes beyond its length, then resets it.
+func WipeBuffer(bb *bytes.Buffer) {
+	bb.Reset()
+	Wipe(bb.Bytes()[:bb.Cap()])
+}
@@ -0,0 +1,276 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/fadhilkurnia/shamir/csprng"
+	"github.com/fadhilkurnia/shamir/krawczyk"
+	"github.com/fadhilkurnia/shamir/shamir"
+	"sync"
+	"time"
+)
+
+// AlgAuto picks shamir or SSMS for each secret based on its size,
+// see CalibrationTable. The 1-of-n secrets are replicated and the
+// n-of-n secrets are XOR-ed with random pads instead. The first split
+// of each (n,k) pair blocks on its calibration, unless
+// CalibrationTable.WarmUp was called for the pair beforehand.
+const AlgAuto = "auto"
+
+// the envelope is the first byte of every AlgAuto share,
+// identifying the scheme used to split the secret
+const (
+	envelopeShamir byte = iota + 1
+	envelopeSSMS
+	envelopeReplication
+	envelopeXOR
+)
+
+// calibration sizes, from minCalibrationSize up to maxCalibrationSize bytes,
+// each split is timed in calibrationRounds windows of calibrationPeriod
+const (
+	minCalibrationSize = 16
+	maxCalibrationSize = 64 << 10
+	calibrationPeriod  = time.Millisecond
+	calibrationRounds  = 5
+)
+
+type calibrationKey struct {
+	n, k int
+}
+
+// calibration is the crossover size of a single (n, k), calibrated once
+// even when requested by concurrent splits.
+type calibration struct {
+	once      sync.Once
+	crossover int
+	err       error
+}
+
+// CalibrationTable holds, for each (n, k), the crossover secret size
+// starting from which SSMS splits faster than shamir on this host.
+// Missing entries are calibrated on first use, call WarmUp beforehand
+// so no split pays for the calibration.
+type CalibrationTable struct {
+	mu           sync.Mutex
+	calibrations map[calibrationKey]*calibration
+}
+
+// DefaultCalibration is the calibration table used by AlgAuto.
+var DefaultCalibration = NewCalibrationTable()
+
+func NewCalibrationTable() *CalibrationTable {
+	return &CalibrationTable{
+		calibrations: map[calibrationKey]*calibration{},
+	}
+}
+
+// Set overrides the crossover size of (n, k), for example with
+// the result of an earlier calibration.
+func (c *CalibrationTable) Set(n, k, crossover int) {
+	e := &calibration{crossover: crossover}
+	e.once.Do(func() {})
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calibrations[calibrationKey{n, k}] = e
+}
+
+// Crossover returns the crossover size of (n, k), running the calibration
+// if it is not known yet. The calibration of (n, k) runs once, without
+// blocking the lookups of the other entries. A failed calibration is
+// retried on the next call.
+func (c *CalibrationTable) Crossover(n, k int) (int, error) {
+	key := calibrationKey{n, k}
+	c.mu.Lock()
+	e, ok := c.calibrations[key]
+	if !ok {
+		e = &calibration{}
+		c.calibrations[key] = e
+	}
+	c.mu.Unlock()
+
+	e.once.Do(func() {
+		e.crossover, e.err = Calibrate(n, k)
+	})
+	if e.err != nil {
+		c.mu.Lock()
+		if c.calibrations[key] == e {
+			delete(c.calibrations, key)
+		}
+		c.mu.Unlock()
+		return 0, e.err
+	}
+	return e.crossover, nil
+}
+
+// WarmUp calibrates the given (n, k) pairs ahead of the splits, so the
+// first AlgAuto split of each pair does not wait for the calibration.
+// The pairs already known are skipped.
+func (c *CalibrationTable) WarmUp(pairs ...[2]int) error {
+	for _, pair := range pairs {
+		if _, err := c.Crossover(pair[0], pair[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Calibrate measures shamir and SSMS splitting on this host for secrets
+// of doubling sizes, and returns the smallest size where SSMS is not
+// slower than shamir. Each split is timed in several windows, alternating
+// between the two schemes, and the fastest window is kept to filter out
+// the noise. Calibrate takes up to about 130 milliseconds, 13 sizes timed
+// in 10 windows of calibrationPeriod, more when a single split outlasts
+// the window.
+func Calibrate(n, k int) (int, error) {
+	r := csprng.NewCSPRNG()
+	s, err := krawczyk.NewSplitter(n, k)
+	if err != nil {
+		return 0, err
+	}
+	ssms := func(secret []byte) error {
+		_, err := s.SplitWithRandomizer(secret, r)
+		return err
+	}
+	ss := func(secret []byte) error {
+		_, err := shamir.SplitWithRandomizer(secret, n, k, r)
+		return err
+	}
+
+	for size := minCalibrationSize; size <= maxCalibrationSize; size *= 2 {
+		secret := make([]byte, size)
+		var ssmsTime, ssTime time.Duration
+		for round := 0; round < calibrationRounds; round++ {
+			t, err := timeSplit(ssms, secret)
+			if err != nil {
+				return 0, err
+			}
+			if round == 0 || t < ssmsTime {
+				ssmsTime = t
+			}
+			if t, err = timeSplit(ss, secret); err != nil {
+				return 0, err
+			}
+			if round == 0 || t < ssTime {
+				ssTime = t
+			}
+		}
+		if ssmsTime <= ssTime {
+			return size, nil
+		}
+	}
+	return 2 * maxCalibrationSize, nil
+}
+
+// timeSplit returns the average duration of split, repeated for
+// at least calibrationPeriod.
+func timeSplit(split func(secret []byte) error, secret []byte) (time.Duration, error) {
+	count := 0
+	start := time.Now()
+	for time.Since(start) < calibrationPeriod {
+		if err := split(secret); err != nil {
+			return 0, err
+		}
+		count++
+	}
+	return time.Since(start) / time.Duration(count), nil
+}
+
+// autoScheme splits with shamir below the crossover size and with SSMS
+// above it, or with replication or XOR for the 1-of-n and n-of-n cases.
+// Each share is prefixed with the envelope of the chosen scheme.
+type autoScheme struct {
+	table *CalibrationTable
+}
+
+func (autoScheme) Name() string {
+	return AlgAuto
+}
+
+func (a autoScheme) choose(secretLen, n, k int) (byte, ContextScheme, error) {
+	switch {
+	case k == 1 && n >= 1:
+		return envelopeReplication, replicationScheme{}, nil
+	case k == n && n > 1:
+		return envelopeXOR, xorScheme{}, nil
+	}
+	crossover, err := a.table.Crossover(n, k)
+	if err != nil {
+		return 0, nil, err
+	}
+	if secretLen < crossover {
+		return envelopeShamir, shamir.Scheme{}, nil
+	}
+	return envelopeSSMS, krawczyk.NewScheme(), nil
+}
+
+func (a autoScheme) Split(secret []byte, n, k int, randomizer *csprng.CSPRNG) ([][]byte, error) {
+	return a.SplitContext(context.Background(), secret, n, k, randomizer)
+}
+
+func (a autoScheme) SplitContext(ctx context.Context, secret []byte, n, k int, randomizer *csprng.CSPRNG) ([][]byte, error) {
+	envelope, s, err := a.choose(len(secret), n, k)
+	if err != nil {
+		return nil, err
+	}
+	shares, err := s.SplitContext(ctx, secret, n, k, randomizer)
+	if err != nil {
+		return nil, err
+	}
+
+	buff := make([]byte, len(shares)*(len(shares[0])+1))
+	for i, share := range shares {
+		shares[i] = buff[: len(share)+1 : len(share)+1]
+		shares[i][0] = envelope
+		copy(shares[i][1:], share)
+		buff = buff[len(share)+1:]
+	}
+	return shares, nil
+}
+
+func (a autoScheme) Combine(shares [][]byte) ([]byte, error) {
+	return a.CombineContext(context.Background(), shares)
+}
+
+func (autoScheme) CombineContext(ctx context.Context, shares [][]byte) ([]byte, error) {
+	var envelope byte
+	opened := make([][]byte, len(shares))
+	for i, share := range shares {
+		if share == nil {
+			continue
+		}
+		if len(share) == 0 {
+			return nil, errors.New("the given secret-shared data is too short")
+		}
+		if envelope == 0 {
+			envelope = share[0]
+		}
+		if share[0] != envelope {
+			return nil, errors.New("all the secret-shared data must have the same envelope")
+		}
+		opened[i] = share[1:]
+	}
+
+	switch envelope {
+	case envelopeShamir:
+		return shamir.Scheme{}.CombineContext(ctx, opened)
+	case envelopeSSMS:
+		return krawczyk.NewScheme().CombineContext(ctx, opened)
+	case envelopeReplication:
+		return replicationScheme{}.CombineContext(ctx, opened)
+	case envelopeXOR:
+		return xorScheme{}.CombineContext(ctx, opened)
+	case 0:
+		return nil, errors.New("no secret-shared data is given")
+	}
+	return nil, fmt.Errorf("unknown envelope %d", envelope)
+}
+
+func (a autoScheme) ShareOverhead(secretLen, n, k int) int {
+	_, s, err := a.choose(secretLen, n, k)
+	if err != nil {
+		return 0
+	}
+	return 1 + s.ShareOverhead(secretLen, n, k)
+}
@@ -0,0 +1,102 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/fadhilkurnia/shamir/csprng"
+	"github.com/fadhilkurnia/shamir/shamir"
+)
+
+// replicationScheme is the 1-of-n scheme used by AlgAuto:
+// every share is a copy of the secret.
+type replicationScheme struct{}
+
+func (replicationScheme) Name() string {
+	return "replication"
+}
+
+func (s replicationScheme) Split(secret []byte, n, k int, randomizer *csprng.CSPRNG) ([][]byte, error) {
+	return s.SplitContext(context.Background(), secret, n, k, randomizer)
+}
+
+func (replicationScheme) SplitContext(ctx context.Context, secret []byte, n, k int, _ *csprng.CSPRNG) ([][]byte, error) {
+	if k != 1 || n < 1 {
+		return nil, fmt.Errorf("replication requires a threshold of 1, but got %d-of-%d", k, n)
+	}
+	if len(secret) == 0 {
+		return nil, errors.New("cannot split an empty secret")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	buff := make([]byte, n*len(secret))
+	shares := make([][]byte, n)
+	for i := range shares {
+		shares[i] = buff[i*len(secret) : (i+1)*len(secret) : (i+1)*len(secret)]
+		copy(shares[i], secret)
+	}
+	return shares, nil
+}
+
+func (s replicationScheme) Combine(shares [][]byte) ([]byte, error) {
+	return s.CombineContext(context.Background(), shares)
+}
+
+func (replicationScheme) CombineContext(ctx context.Context, shares [][]byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, share := range shares {
+		if share != nil {
+			return append([]byte(nil), share...), nil
+		}
+	}
+	return nil, errors.New("no secret-shared data is given")
+}
+
+func (replicationScheme) ShareOverhead(secretLen, n, k int) int {
+	return 0
+}
+
+// xorScheme is the n-of-n scheme used by AlgAuto, see shamir.SplitXOR.
+type xorScheme struct{}
+
+func (xorScheme) Name() string {
+	return "xor"
+}
+
+func (s xorScheme) Split(secret []byte, n, k int, randomizer *csprng.CSPRNG) ([][]byte, error) {
+	return s.SplitContext(context.Background(), secret, n, k, randomizer)
+}
+
+func (xorScheme) SplitContext(ctx context.Context, secret []byte, n, k int, randomizer *csprng.CSPRNG) ([][]byte, error) {
+	if k != n {
+		return nil, fmt.Errorf("xor sharing requires all the parts, but got %d-of-%d", k, n)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return shamir.SplitXOR(secret, n, randomizer)
+}
+
+func (s xorScheme) Combine(shares [][]byte) ([]byte, error) {
+	return s.CombineContext(context.Background(), shares)
+}
+
+func (xorScheme) CombineContext(ctx context.Context, shares [][]byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	parts := make([][]byte, 0, len(shares))
+	for _, share := range shares {
+		if share != nil {
+			parts = append(parts, share)
+		}
+	}
+	return shamir.CombineXOR(parts)
+}
+
+func (xorScheme) ShareOverhead(secretLen, n, k int) int {
+	return secretLen + shamir.XORShareOverhead - (secretLen+k-1)/k
+}
@@ -0,0 +1,192 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+)
+
+// ErrPoolClosed is returned when submitting a job to a closed Pool.
+var ErrPoolClosed = errors.New("the worker pool is closed")
+
+// SplitResult is the result of a split job submitted to a Pool.
+type SplitResult struct {
+	Shares [][]byte
+	Err    error
+}
+
+// CombineResult is the result of a combine job submitted to a Pool.
+type CombineResult struct {
+	Secret []byte
+	Err    error
+}
+
+// Pool runs secret-sharing jobs on a fixed number of workers, each with
+// its own randomization source. Jobs are queued in a bounded queue, when
+// the queue is full the submission blocks until a worker picks a job.
+type Pool struct {
+	jobs chan func(w *Worker)
+	wg   sync.WaitGroup
+
+	// mu guards closed, submitters hold the read lock while queueing
+	// so Close never closes the queue under a blocked submitter
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewPool starts `numWorkers` workers, or one worker per CPU when
+// numWorkers is not positive, with a queue of `queueSize` pending jobs.
+func NewPool(numWorkers, queueSize int) *Pool {
+	if numWorkers <= 0 {
+		numWorkers = runtime.NumCPU()
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+
+	p := &Pool{
+		jobs: make(chan func(w *Worker), queueSize),
+	}
+	p.wg.Add(numWorkers)
+	for i := 0; i < numWorkers; i++ {
+		go func() {
+			defer p.wg.Done()
+			w := NewWorker()
+			for job := range p.jobs {
+				job(&w)
+			}
+		}()
+	}
+	return p
+}
+
+func (p *Pool) submit(job func(w *Worker)) error {
+	return p.submitContext(context.Background(), job)
+}
+
+// submitContext stops waiting for room in the queue once ctx is done.
+func (p *Pool) submitContext(ctx context.Context, job func(w *Worker)) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrPoolClosed
+	}
+	select {
+	case p.jobs <- job:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SubmitSplit queues a split job and returns the channel receiving its
+// result. It blocks while the queue is full.
+func (p *Pool) SubmitSplit(algorithm string, input []byte, n, k int) (<-chan SplitResult, error) {
+	result := make(chan SplitResult, 1)
+	err := p.submit(func(w *Worker) {
+		shares, err := w.Split(algorithm, input, n, k)
+		result <- SplitResult{shares, err}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SubmitCombine queues a combine job and returns the channel receiving its
+// result. It blocks while the queue is full.
+func (p *Pool) SubmitCombine(algorithm string, secretSharedData [][]byte) (<-chan CombineResult, error) {
+	result := make(chan CombineResult, 1)
+	err := p.submit(func(w *Worker) {
+		secret, err := w.Combine(algorithm, secretSharedData)
+		result <- CombineResult{secret, err}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SubmitSplitContext is similar with SubmitSplit, but the job is cancelled
+// once ctx is done: the submission stops waiting for room in the queue and
+// returns ctx.Err(), and a queued or running job results in ctx.Err().
+func (p *Pool) SubmitSplitContext(ctx context.Context, algorithm string, input []byte, n, k int) (<-chan SplitResult, error) {
+	result := make(chan SplitResult, 1)
+	err := p.submitContext(ctx, func(w *Worker) {
+		shares, err := w.SplitContext(ctx, algorithm, input, n, k)
+		result <- SplitResult{shares, err}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SubmitCombineContext is similar with SubmitCombine, but the job is
+// cancelled once ctx is done, see SubmitSplitContext.
+func (p *Pool) SubmitCombineContext(ctx context.Context, algorithm string, secretSharedData [][]byte) (<-chan CombineResult, error) {
+	result := make(chan CombineResult, 1)
+	err := p.submitContext(ctx, func(w *Worker) {
+		secret, err := w.CombineContext(ctx, algorithm, secretSharedData)
+		result <- CombineResult{secret, err}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Split submits a split job and waits for its result.
+func (p *Pool) Split(algorithm string, input []byte, n, k int) ([][]byte, error) {
+	result, err := p.SubmitSplit(algorithm, input, n, k)
+	if err != nil {
+		return nil, err
+	}
+	r := <-result
+	return r.Shares, r.Err
+}
+
+// Combine submits a combine job and waits for its result.
+func (p *Pool) Combine(algorithm string, secretSharedData [][]byte) ([]byte, error) {
+	result, err := p.SubmitCombine(algorithm, secretSharedData)
+	if err != nil {
+		return nil, err
+	}
+	r := <-result
+	return r.Secret, r.Err
+}
+
+// SplitContext submits a split job and waits for its result,
+// see SubmitSplitContext.
+func (p *Pool) SplitContext(ctx context.Context, algorithm string, input []byte, n, k int) ([][]byte, error) {
+	result, err := p.SubmitSplitContext(ctx, algorithm, input, n, k)
+	if err != nil {
+		return nil, err
+	}
+	r := <-result
+	return r.Shares, r.Err
+}
+
+// CombineContext submits a combine job and waits for its result,
+// see SubmitSplitContext.
+func (p *Pool) CombineContext(ctx context.Context, algorithm string, secretSharedData [][]byte) ([]byte, error) {
+	result, err := p.SubmitCombineContext(ctx, algorithm, secretSharedData)
+	if err != nil {
+		return nil, err
+	}
+	r := <-result
+	return r.Secret, r.Err
+}
+
+// Close stops accepting new jobs, then waits until all the queued and
+// running jobs are finished. Close can be called more than once.
+func (p *Pool) Close() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.jobs)
+	}
+	p.mu.Unlock()
+	p.wg.Wait()
+}
@@ -0,0 +1,113 @@
+package worker
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPoolSplitCombine(t *testing.T) {
+	secretMsg := []byte("The quick brown fox jumps over the lazy dog.")
+	p := NewPool(4, 2)
+	defer p.Close()
+
+	for _, algorithm := range []string{AlgShamir, AlgSSMS, AlgAONTRS} {
+		results := make([]<-chan SplitResult, 32)
+		for i := range results {
+			var err error
+			if results[i], err = p.SubmitSplit(algorithm, secretMsg, 5, 3); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for i := range results {
+			r := <-results[i]
+			if r.Err != nil {
+				t.Fatalf("failed to split the message with %s: %v", algorithm, r.Err)
+			}
+			combinedShares, err := p.Combine(algorithm, r.Shares[2:])
+			if err != nil {
+				t.Fatalf("failed to combine the message with %s: %v", algorithm, err)
+			}
+			if !reflect.DeepEqual(secretMsg, combinedShares) {
+				t.Errorf("The combined secret is different. Expected: '%v', but got '%v'.\n", string(secretMsg), string(combinedShares))
+			}
+		}
+	}
+}
+
+func TestPoolClose(t *testing.T) {
+	secretMsg := []byte("The quick brown fox jumps over the lazy dog.")
+	p := NewPool(2, 1)
+
+	// the submitters are blocked by the small queue while closing,
+	// all the submitted jobs must still be finished
+	var wg sync.WaitGroup
+	results := make(chan (<-chan SplitResult), 64)
+	for i := 0; i < 64; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if result, err := p.SubmitSplit(AlgShamir, secretMsg, 4, 2); err == nil {
+				results <- result
+			} else if err != ErrPoolClosed {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	p.Close()
+	wg.Wait()
+	close(results)
+	for result := range results {
+		if r := <-result; r.Err != nil || len(r.Shares) != 4 {
+			t.Errorf("expecting 4 shares, but got %d shares and error %v", len(r.Shares), r.Err)
+		}
+	}
+
+	if _, err := p.SubmitSplit(AlgShamir, secretMsg, 4, 2); err != ErrPoolClosed {
+		t.Errorf("expecting ErrPoolClosed after closing the pool, but got %v", err)
+	}
+	p.Close()
+}
+
+func TestPoolContext(t *testing.T) {
+	secretMsg := []byte("The quick brown fox jumps over the lazy dog.")
+	p := NewPool(1, 0)
+	defer p.Close()
+
+	for _, algorithm := range []string{AlgShamir, AlgSSMS, AlgAONTRS, AlgAuto} {
+		shares, err := p.SplitContext(context.Background(), algorithm, secretMsg, 5, 3)
+		if err != nil {
+			t.Fatalf("failed to split the message with %s: %v", algorithm, err)
+		}
+		combinedShares, err := p.CombineContext(context.Background(), algorithm, shares[2:])
+		if err != nil {
+			t.Fatalf("failed to combine the message with %s: %v", algorithm, err)
+		}
+		if !reflect.DeepEqual(secretMsg, combinedShares) {
+			t.Errorf("The combined secret is different. Expected: '%v', but got '%v'.\n", string(secretMsg), string(combinedShares))
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := p.SplitContext(ctx, algorithm, secretMsg, 5, 3); err != context.Canceled {
+			t.Errorf("expecting context.Canceled when splitting with %s, but got %v", algorithm, err)
+		}
+		if _, err := p.CombineContext(ctx, algorithm, shares[2:]); err != context.Canceled {
+			t.Errorf("expecting context.Canceled when combining with %s, but got %v", algorithm, err)
+		}
+	}
+
+	// a submission waiting for a busy worker gives up once ctx is done
+	block := make(chan struct{})
+	if err := p.submit(func(w *Worker) { <-block }); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.SubmitSplitContext(ctx, AlgShamir, secretMsg, 5, 3); err != context.DeadlineExceeded {
+		t.Errorf("expecting context.DeadlineExceeded, but got %v", err)
+	}
+	close(block)
+}
@@ -0,0 +1,73 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"github.com/fadhilkurnia/shamir/aontrs"
+	"github.com/fadhilkurnia/shamir/csprng"
+	"github.com/fadhilkurnia/shamir/krawczyk"
+	"github.com/fadhilkurnia/shamir/shamir"
+	"sync"
+)
+
+// Scheme is a secret-sharing scheme usable by the workers. The shares
+// must carry everything needed to combine them, other than the scheme.
+type Scheme interface {
+	// Name identifies the scheme in the registry.
+	Name() string
+	// Split secret-shares the secret into n shares, k of which are
+	// required to reconstruct the secret.
+	Split(secret []byte, n, k int, randomizer *csprng.CSPRNG) ([][]byte, error)
+	// Combine reconstructs the secret from the shares, the shares for
+	// missing parts can be given as nil.
+	Combine(shares [][]byte) ([]byte, error)
+	// ShareOverhead returns how many bytes each share is larger than
+	// len(secret)/k for a secret of secretLen bytes.
+	ShareOverhead(secretLen, n, k int) int
+}
+
+// ContextScheme is a Scheme that can stop splitting and combining
+// once a context is done. The workers use it when a scheme implements it,
+// otherwise the context is only checked before the job starts.
+type ContextScheme interface {
+	Scheme
+	// SplitContext is Split, returning ctx.Err() once ctx is done.
+	SplitContext(ctx context.Context, secret []byte, n, k int, randomizer *csprng.CSPRNG) ([][]byte, error)
+	// CombineContext is Combine, returning ctx.Err() once ctx is done.
+	CombineContext(ctx context.Context, shares [][]byte) ([]byte, error)
+}
+
+var (
+	schemesMu sync.RWMutex
+	schemes   = map[string]Scheme{}
+)
+
+func init() {
+	for _, s := range []Scheme{shamir.Scheme{}, krawczyk.NewScheme(), aontrs.Scheme{}, autoScheme{DefaultCalibration}} {
+		if err := Register(s); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Register makes the scheme available to the workers under its name.
+func Register(s Scheme) error {
+	schemesMu.Lock()
+	defer schemesMu.Unlock()
+	if _, ok := schemes[s.Name()]; ok {
+		return fmt.Errorf("secret-sharing scheme %q is already registered", s.Name())
+	}
+	schemes[s.Name()] = s
+	return nil
+}
+
+// Lookup returns the registered scheme with the given name.
+func Lookup(name string) (Scheme, error) {
+	schemesMu.RLock()
+	defer schemesMu.RUnlock()
+	s, ok := schemes[name]
+	if !ok {
+		return nil, fmt.Errorf("invalid secret-sharing algorithm %q", name)
+	}
+	return s, nil
+}
@@ -1,14 +1,14 @@
 package worker
 
 import (
-	"errors"
+	"context"
 	"github.com/fadhilkurnia/shamir/csprng"
-	"github.com/fadhilkurnia/shamir/krawczyk"
-	"github.com/fadhilkurnia/shamir/shamir"
 )
 
+// names of the built-in secret-sharing schemes
 const AlgShamir = "shamir"
 const AlgSSMS = "krawczyk"
+const AlgAONTRS = "aontrs"
 
 // Worker is a secret-sharing worker with a single randomization source
 type Worker struct {
@@ -22,21 +22,49 @@ func NewWorker() Worker {
 }
 
 func (w *Worker) Split(algorithm string, input []byte, n, k int) ([][]byte, error) {
-	if algorithm != AlgShamir && algorithm != AlgSSMS {
-		return nil, errors.New("invalid secret-sharing algorithm")
+	s, err := Lookup(algorithm)
+	if err != nil {
+		return nil, err
 	}
-	if algorithm == AlgShamir {
-		return shamir.SplitWithRandomizer(input, n, k, w.r)
+	return s.Split(input, n, k, w.r)
+}
+
+func (w *Worker) Combine(algorithm string, secretSharedData [][]byte) ([]byte, error) {
+	s, err := Lookup(algorithm)
+	if err != nil {
+		return nil, err
 	}
-	return krawczyk.SplitWithRandomizer(input, n, k, w.r)
+	return s.Combine(secretSharedData)
 }
 
-func (w *Worker) Combine(algorithm string, secretSharedData [][]byte, n, k int) ([]byte, error){
-	if algorithm != AlgShamir && algorithm != AlgSSMS {
-		return nil, errors.New("invalid secret-sharing algorithm")
+// SplitContext is similar with Split, but returns ctx.Err() once ctx is
+// done, see ContextScheme.
+func (w *Worker) SplitContext(ctx context.Context, algorithm string, input []byte, n, k int) ([][]byte, error) {
+	s, err := Lookup(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
-	if algorithm == AlgShamir {
-		return shamir.Combine(secretSharedData)
+	if cs, ok := s.(ContextScheme); ok {
+		return cs.SplitContext(ctx, input, n, k, w.r)
 	}
-	return krawczyk.Combine(secretSharedData, n, k)
-}
\ No newline at end of file
+	return s.Split(input, n, k, w.r)
+}
+
+// CombineContext is similar with Combine, but returns ctx.Err() once ctx
+// is done, see ContextScheme.
+func (w *Worker) CombineContext(ctx context.Context, algorithm string, secretSharedData [][]byte) ([]byte, error) {
+	s, err := Lookup(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if cs, ok := s.(ContextScheme); ok {
+		return cs.CombineContext(ctx, secretSharedData)
+	}
+	return s.Combine(secretSharedData)
+}
@@ -0,0 +1,195 @@
+package worker
+
+import (
+	"errors"
+	"github.com/fadhilkurnia/shamir/csprng"
+	"math/rand"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// replication stores a copy of the secret in every share
+type replication struct{}
+
+func (replication) Name() string { return "test-replication" }
+
+func (replication) Split(secret []byte, n, k int, randomizer *csprng.CSPRNG) ([][]byte, error) {
+	shares := make([][]byte, n)
+	for i := range shares {
+		shares[i] = append([]byte(nil), secret...)
+	}
+	return shares, nil
+}
+
+func (replication) Combine(shares [][]byte) ([]byte, error) {
+	for _, share := range shares {
+		if share != nil {
+			return share, nil
+		}
+	}
+	return nil, errors.New("no secret-shared data is given")
+}
+
+func (replication) ShareOverhead(secretLen, n, k int) int {
+	return secretLen - (secretLen+k-1)/k
+}
+
+func TestRegister(t *testing.T) {
+	secretMsg := []byte("The quick brown fox jumps over the lazy dog.")
+	if err := Register(replication{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Register(replication{}); err == nil {
+		t.Errorf("expecting an error when registering a scheme twice")
+	}
+
+	w := NewWorker()
+	shares, err := w.Split("test-replication", secretMsg, 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	combinedShares, err := w.Combine("test-replication", [][]byte{nil, shares[1]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(secretMsg, combinedShares) {
+		t.Errorf("The combined secret is different. Expected: '%v', but got '%v'.\n", string(secretMsg), string(combinedShares))
+	}
+
+	if _, err := w.Split("unknown", secretMsg, 3, 2); err == nil {
+		t.Errorf("expecting an error when splitting with an unknown scheme")
+	}
+}
+
+func TestShareOverhead(t *testing.T) {
+	w := NewWorker()
+	for _, name := range []string{AlgShamir, AlgSSMS, AlgAONTRS, AlgAuto} {
+		s, err := Lookup(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for size := 1; size < 200; size += 17 {
+			shares, err := w.Split(name, make([]byte, size), 5, 3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectedLen := (size+2)/3 + s.ShareOverhead(size, 5, 3)
+			if len(shares[0]) != expectedLen {
+				t.Errorf("the expected length of a %s share is %d, but got %d", name, expectedLen, len(shares[0]))
+			}
+		}
+	}
+}
+
+func TestAuto(t *testing.T) {
+	w := NewWorker()
+	DefaultCalibration.Set(4, 2, 100)
+
+	for _, size := range []int{50, 200} {
+		secretMsg := make([]byte, size)
+		rand.Read(secretMsg)
+		shares, err := w.Split(AlgAuto, secretMsg, 4, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedEnvelope := envelopeShamir
+		if size >= 100 {
+			expectedEnvelope = envelopeSSMS
+		}
+		if shares[0][0] != expectedEnvelope {
+			t.Errorf("expecting envelope %d for %d bytes secret, but got %d", expectedEnvelope, size, shares[0][0])
+		}
+
+		combinedShares, err := w.Combine(AlgAuto, [][]byte{nil, shares[1], nil, shares[3]})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(secretMsg, combinedShares) {
+			t.Errorf("The combined secret is different. Expected: '%v', but got '%v'.\n", string(secretMsg), string(combinedShares))
+		}
+	}
+
+	crossover, err := Calibrate(4, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("crossover size for n=4 k=2: %d bytes", crossover)
+	if crossover < minCalibrationSize {
+		t.Errorf("expecting the crossover size to be at least %d, but got %d", minCalibrationSize, crossover)
+	}
+}
+
+func TestAutoDegenerate(t *testing.T) {
+	w := NewWorker()
+	secretMsg := []byte("The quick brown fox jumps over the lazy dog")
+
+	for _, c := range []struct {
+		n, k     int
+		envelope byte
+	}{
+		{4, 1, envelopeReplication}, {1, 1, envelopeReplication}, {4, 4, envelopeXOR}, {2, 2, envelopeXOR},
+	} {
+		shares, err := w.Split(AlgAuto, secretMsg, c.n, c.k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if shares[0][0] != c.envelope {
+			t.Errorf("expecting envelope %d for %d-of-%d, but got %d", c.envelope, c.k, c.n, shares[0][0])
+		}
+		s, _ := Lookup(AlgAuto)
+		expectedLen := (len(secretMsg)+c.k-1)/c.k + s.ShareOverhead(len(secretMsg), c.n, c.k)
+		if len(shares[0]) != expectedLen {
+			t.Errorf("the expected length of a %d-of-%d share is %d, but got %d", c.k, c.n, expectedLen, len(shares[0]))
+		}
+
+		// keep only k of the shares
+		present := make([][]byte, c.n)
+		copy(present[c.n-c.k:], shares[c.n-c.k:])
+		combinedShares, err := w.Combine(AlgAuto, present)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(secretMsg, combinedShares) {
+			t.Errorf("The combined secret is different. Expected: '%v', but got '%v'.\n", string(secretMsg), string(combinedShares))
+		}
+		if c.envelope == envelopeXOR {
+			present[c.n-1] = nil
+			if _, err := w.Combine(AlgAuto, present); err == nil {
+				t.Errorf("expecting an error when combining %d-of-%d shares with a missing share", c.k, c.n)
+			}
+		}
+	}
+}
+
+func TestCalibrationTable(t *testing.T) {
+	table := NewCalibrationTable()
+	table.Set(4, 2, 100)
+	if err := table.WarmUp([2]int{4, 2}, [2]int{5, 3}); err != nil {
+		t.Fatal(err)
+	}
+	if crossover, _ := table.Crossover(4, 2); crossover != 100 {
+		t.Errorf("expecting the crossover size set to 100, but got %d", crossover)
+	}
+
+	// concurrent lookups of an uncalibrated pair share a single calibration
+	crossovers := make([]int, 8)
+	wg := sync.WaitGroup{}
+	for i := range crossovers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			crossovers[i], _ = table.Crossover(6, 3)
+		}(i)
+	}
+	wg.Wait()
+	for i, crossover := range crossovers {
+		if crossover != crossovers[0] {
+			t.Errorf("expecting the same crossover size for all lookups, but got %d and %d", crossovers[0], crossovers[i])
+		}
+	}
+
+	if _, err := table.Crossover(2, 3); err == nil {
+		t.Errorf("expecting an error when calibrating with threshold larger than parts")
+	}
+}